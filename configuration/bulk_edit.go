@@ -0,0 +1,207 @@
+package configuration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	FieldServiceProfileName = "serviceProfileName"
+	FieldPublishedHostname  = "publishedHostname"
+	FieldSourceHostname     = "sourceHostname"
+	FieldPublishedURLPath   = "publishedUrlPath"
+	FieldSourceURLPath      = "sourceUrlPath"
+)
+
+// DefaultBulkEditConcurrency is the number of updates ApplyBulkEdit runs at once when no concurrency is given
+const DefaultBulkEditConcurrency = 4
+
+// BodyTransform edits a body in place
+type BodyTransform func(body *DeliveryServiceInstanceBody) error
+
+// Replacement replaces every occurrence of Old with New in the named field. Old must not be empty.
+type Replacement struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// ReplaceTransform makes a BodyTransform applying the said replacements in order. Replacements of unknown fields, or
+// with an empty Old, which would insert New between every character of the field, are rejected.
+func ReplaceTransform(replacements ...Replacement) (BodyTransform, error) {
+	for i, replacement := range replacements {
+		switch replacement.Field {
+		case FieldServiceProfileName, FieldPublishedHostname, FieldSourceHostname, FieldPublishedURLPath, FieldSourceURLPath:
+		default:
+			return nil, fmt.Errorf("replacement %d: cannot replace in unknown field %q", i, replacement.Field)
+		}
+		if replacement.Old == "" {
+			return nil, fmt.Errorf("replacement %d: nothing to replace in %s", i, replacement.Field)
+		}
+	}
+
+	return func(body *DeliveryServiceInstanceBody) error {
+		for _, replacement := range replacements {
+			var field *string
+			switch replacement.Field {
+			case FieldServiceProfileName:
+				field = &body.ServiceProfileName
+			case FieldPublishedHostname:
+				field = &body.PublishedHostname
+			case FieldSourceHostname:
+				field = &body.SourceHostname
+			case FieldPublishedURLPath:
+				field = &body.PublishedURLPath
+			case FieldSourceURLPath:
+				field = &body.SourceURLPath
+			}
+			*field = strings.Replace(*field, replacement.Old, replacement.New, -1)
+		}
+		return nil
+	}, nil
+}
+
+// BulkEditChange is the edit planned for a single instance
+type BulkEditChange struct {
+	Instance    *DeliveryServiceInstance
+	Edited      *DeliveryServiceInstanceBody
+	Differences []BodyDifference
+}
+
+// BulkEdit lists the instances a transform changes. Instances the transform leaves unchanged are not included.
+type BulkEdit struct {
+	Shortname string
+	Changes   []BulkEditChange
+}
+
+// Diff combines the differences of every change into a single reviewable listing
+func (e *BulkEdit) Diff() string {
+	var builder strings.Builder
+	for _, change := range e.Changes {
+		fmt.Fprintf(&builder, "%s (%s)\n", DeliveryServiceInstanceKeyOf(&change.Instance.Body), change.Instance.UUID)
+		for _, difference := range change.Differences {
+			fmt.Fprintf(&builder, "    %s\n", difference)
+		}
+	}
+	fmt.Fprintf(&builder, "%d delivery service instances to update\n", len(e.Changes))
+	return builder.String()
+}
+
+// BulkEditOptions controls how a BulkEdit is applied. With Rollback set, the instances that were updated are restored
+// to their original bodies if any update fails.
+type BulkEditOptions struct {
+	Concurrency int
+	Rollback    bool
+}
+
+// BulkEditResult is the outcome of applying a BulkEditChange, and of rolling it back if that was needed
+type BulkEditResult struct {
+	UUID        string
+	Instance    *DeliveryServiceInstance
+	Response    *http.Response
+	Err         error
+	RolledBack  bool
+	RollbackErr error
+}
+
+// Applied reports whether the update reached the server, even if its response could not be coerced
+func (r *BulkEditResult) Applied() bool {
+	return r.Err == nil || IsResponseCoercionError(r.Err)
+}
+
+// PlanBulkEdit applies transform to a copy of every instance of the shortname matched by filter, keeping those the
+// transform changes
+func (c *ConfigurationClient) PlanBulkEdit(shortname string, filter DeliveryServiceInstanceFilter, transform BodyTransform) (*BulkEdit, error) {
+	instances, _, err := c.ListDeliveryServiceInstances(shortname)
+	if err != nil {
+		return nil, err
+	}
+	return PlanBulkEditChanges(shortname, instances, filter, transform)
+}
+
+// PlanBulkEditChanges is PlanBulkEdit for instances that have already been fetched
+func PlanBulkEditChanges(shortname string, instances []DeliveryServiceInstance, filter DeliveryServiceInstanceFilter, transform BodyTransform) (*BulkEdit, error) {
+	edit := &BulkEdit{Shortname: shortname}
+	for i := range instances {
+		if filter != nil && !filter(&instances[i]) {
+			continue
+		}
+		edited := CopyDeliveryServiceInstanceBody(&instances[i].Body)
+		if err := transform(edited); err != nil {
+			return nil, fmt.Errorf("editing delivery service instance %s: %s", instances[i].UUID, err)
+		}
+		differences := DiffDeliveryServiceInstanceBodies(&instances[i].Body, edited)
+		if len(differences) > 0 {
+			edit.Changes = append(edit.Changes, BulkEditChange{Instance: &instances[i], Edited: edited, Differences: differences})
+		}
+	}
+	return edit, nil
+}
+
+// ApplyBulkEdit updates every instance of the edit concurrently. Requests still pass through the client's rate
+// limiter, so concurrency only overlaps their latency. The results are in the order of the changes.
+func (c *ConfigurationClient) ApplyBulkEdit(edit *BulkEdit, options BulkEditOptions) []BulkEditResult {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBulkEditConcurrency
+	}
+
+	results := make([]BulkEditResult, len(edit.Changes))
+	c.forEachConcurrently(len(edit.Changes), concurrency, func(i int) {
+		change := edit.Changes[i]
+		results[i].UUID = change.Instance.UUID
+		results[i].Instance, results[i].Response, results[i].Err = c.updateKeepingAccounts(edit.Shortname, change.Instance, change.Edited)
+	})
+
+	if !options.Rollback || !anyBulkEditFailed(results) {
+		return results
+	}
+	c.forEachConcurrently(len(edit.Changes), concurrency, func(i int) {
+		if !results[i].Applied() {
+			return
+		}
+		change := edit.Changes[i]
+		_, _, results[i].RollbackErr = c.updateKeepingAccounts(edit.Shortname, change.Instance, &change.Instance.Body)
+		results[i].RolledBack = results[i].RollbackErr == nil || IsResponseCoercionError(results[i].RollbackErr)
+	})
+	return results
+}
+
+// updateKeepingAccounts updates the instance without changing its owners, fetching them for instances listed without
+// their accounts
+func (c *ConfigurationClient) updateKeepingAccounts(shortname string, instance *DeliveryServiceInstance, body *DeliveryServiceInstanceBody) (*DeliveryServiceInstance, *http.Response, error) {
+	if len(instance.Accounts) == 0 {
+		return c.UpdateDeliveryServiceInstance(instance.UUID, body, shortname)
+	}
+	return c.UpdateDeliveryServiceInstanceWithAccounts(instance.UUID, body, instance.Accounts)
+}
+
+func (c *ConfigurationClient) forEachConcurrently(count int, concurrency int, do func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				do(i)
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+func anyBulkEditFailed(results []BulkEditResult) bool {
+	for _, result := range results {
+		if !result.Applied() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package configuration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReplaceTransform(t *testing.T) {
+	transform, err := ReplaceTransform(
+		Replacement{Field: FieldSourceHostname, Old: "origin.com", New: "origin.example.com"},
+		Replacement{Field: FieldSourceURLPath, Old: "/v1", New: "/v2"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := &DeliveryServiceInstanceBody{SourceHostname: "origin.com", SourceURLPath: "/v1/media", PublishedHostname: "origin.com"}
+	if err := transform(body); err != nil {
+		t.Fatal(err)
+	}
+	if body.SourceHostname != "origin.example.com" || body.SourceURLPath != "/v2/media" || body.PublishedHostname != "origin.com" {
+		t.Errorf("transformed body = %+v", body)
+	}
+
+	for _, replacement := range []Replacement{
+		{Field: FieldSourceHostname, Old: "", New: "x"},
+		{Field: "protocolSets", Old: "HTTP", New: "HTTPS"},
+	} {
+		if _, err := ReplaceTransform(replacement); err == nil {
+			t.Errorf("ReplaceTransform(%+v) succeeded, want an error", replacement)
+		}
+	}
+}
+
+func TestApplyBulkEditRollsBackUpdatesWithUncoercibleResponses(t *testing.T) {
+	var lock sync.Mutex
+	updates := map[string][]string{}
+	client, stop := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/configoption/shortname/acct/svcProf/Profile":
+			json.NewEncoder(w).Encode(ConfigOptionsResponse{Results: []ConfigOption{{Body: ConfigOptionBody{
+				Name:    "refresh_absmin",
+				Details: ConfigOptionDetails{Arguments: []ConfigOptionArgument{{Name: "seconds", Type: "Int"}}},
+			}}}})
+		case r.Method == "PUT" && r.URL.Path == "/svcinst/delivery/a":
+			request, _ := ioutil.ReadAll(r.Body)
+			lock.Lock()
+			updates["a"] = append(updates["a"], string(request))
+			lock.Unlock()
+			// the update is applied, but the response carries a parameter the catalog cannot coerce
+			json.NewEncoder(w).Encode(DeliveryServiceInstance{UUID: "a", Accounts: []Account{{Shortname: "acct"}}, Body: DeliveryServiceInstanceBody{
+				ServiceProfileName: "Profile",
+				ProtocolSets:       []ProtocolSet{{Options: []Option{{Name: "refresh_absmin", Parameters: []interface{}{"soon"}}}}},
+			}})
+		default:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	defer stop()
+	client.SetOptionParameterCoercion(true)
+
+	instances := []DeliveryServiceInstance{
+		{UUID: "a", Accounts: []Account{{Shortname: "acct"}}, Body: DeliveryServiceInstanceBody{ServiceProfileName: "Profile", SourceHostname: "old.example.com"}},
+		{UUID: "b", Accounts: []Account{{Shortname: "acct"}}, Body: DeliveryServiceInstanceBody{ServiceProfileName: "Profile", SourceHostname: "old.example.com"}},
+	}
+	transform, err := ReplaceTransform(Replacement{Field: FieldSourceHostname, Old: "old", New: "new"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit, err := PlanBulkEditChanges("acct", instances, nil, transform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := client.ApplyBulkEdit(edit, BulkEditOptions{Concurrency: 1, Rollback: true})
+
+	if !IsResponseCoercionError(results[0].Err) || !results[0].Applied() {
+		t.Errorf("update of a: Err = %v, want a ResponseCoercionError", results[0].Err)
+	}
+	if !results[0].RolledBack {
+		t.Errorf("update of a was not rolled back: %v", results[0].RollbackErr)
+	}
+	if results[1].Applied() || results[1].RolledBack {
+		t.Errorf("update of b: Err = %v, RolledBack = %v, want a failure that is not rolled back", results[1].Err, results[1].RolledBack)
+	}
+	if len(updates["a"]) != 2 || !strings.Contains(updates["a"][1], "old.example.com") {
+		t.Errorf("updates of a = %q, want the edit followed by the original body", updates["a"])
+	}
+}
@@ -1,18 +1,17 @@
 package configuration
 
 import (
-	"sync"
 	"time"
 
 	"github.com/llnw/llnw-sdk-go"
 )
 
 type ConfigurationClient struct {
-	Auth                             *llnw.Auth
-	BaseUrl                          string
-	rateLimiter                      <-chan time.Time
-	configOptionLock                 sync.Mutex
-	configOptionArgumentIntegerCache map[string][]bool
+	Auth                   *llnw.Auth
+	BaseUrl                string
+	rateLimiter            <-chan time.Time
+	configOptionCatalogs   *configOptionCatalogCache
+	coerceOptionParameters bool
 }
 
 func NewClient(apiUser string, apiKey string) *ConfigurationClient {
@@ -29,6 +28,7 @@ func NewClientOverrideBaseUrl(apiUser string, apiKey string, baseUrl string) *Co
 	c.BaseUrl = baseUrl
 
 	c.rateLimiter = time.Tick(1200 * time.Millisecond)
+	c.configOptionCatalogs = newConfigOptionCatalogCache(DefaultConfigOptionCatalogTTL)
 
 	return c
 }
@@ -0,0 +1,18 @@
+package configuration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+// newTestClient returns a client sending its requests to handler without waiting for the rate limiter, and a
+// function to stop the test server
+func newTestClient(handler http.Handler) (*ConfigurationClient, func()) {
+	server := httptest.NewServer(handler)
+	client := NewClientOverrideBaseUrl("user", "key", server.URL)
+	unlimited := make(chan time.Time)
+	close(unlimited)
+	client.rateLimiter = unlimited
+	return client, server.Close
+}
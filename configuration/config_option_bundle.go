@@ -0,0 +1,294 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CatalogBundleVersion is the version of the bundle format written by CatalogBundle.Marshal
+const CatalogBundleVersion = 1
+
+const (
+	CatalogChangeAdded   = "added"
+	CatalogChangeRemoved = "removed"
+	CatalogChangeChanged = "changed"
+)
+
+// CatalogBundle is an offline copy of the config option catalogs of several service profiles. A marshalled bundle
+// can be embedded in a program and loaded with LoadCatalogBundle to validate bodies without network access.
+type CatalogBundle struct {
+	Version   int                       `json:"version"`
+	Shortname string                    `json:"shortname"`
+	CreatedAt time.Time                 `json:"createdAt"`
+	Profiles  map[string][]ConfigOption `json:"profiles"`
+}
+
+// CatalogChange is a single option added, removed or changed between two bundles
+type CatalogChange struct {
+	Profile string
+	Option  string
+	Kind    string
+}
+
+func (c CatalogChange) String() string {
+	return fmt.Sprintf("%s %s/%s", c.Kind, c.Profile, c.Option)
+}
+
+// ExportCatalogBundle fetches the catalogs of the said service profiles, or of every service profile of the
+// shortname if none are given, into a bundle
+func (c *ConfigurationClient) ExportCatalogBundle(shortname string, profileNames ...string) (*CatalogBundle, error) {
+	if len(profileNames) == 0 {
+		profiles, _, err := c.ListServiceProfiles(shortname)
+		if err != nil {
+			return nil, err
+		}
+		for _, profile := range profiles {
+			profileNames = append(profileNames, profile.Name)
+		}
+	}
+
+	bundle := &CatalogBundle{
+		Version:   CatalogBundleVersion,
+		Shortname: shortname,
+		CreatedAt: time.Now().UTC(),
+		Profiles:  map[string][]ConfigOption{},
+	}
+	for _, profileName := range profileNames {
+		configOptions, _, err := c.GetConfigurationOptions(shortname, profileName)
+		if err != nil {
+			return nil, fmt.Errorf("fetching config options of service profile %s: %s", profileName, err)
+		}
+		bundle.Profiles[profileName] = configOptions
+	}
+	return bundle, nil
+}
+
+// UseCatalogBundle seeds the client's catalog cache with every profile of the bundle, so that validation and
+// coercion for the bundle's shortname work without fetching catalogs. The seeded catalogs do not expire.
+func (c *ConfigurationClient) UseCatalogBundle(bundle *CatalogBundle) {
+	for _, profileName := range bundle.ProfileNames() {
+		catalog, _ := bundle.Catalog(profileName)
+		c.configOptionCatalogs.pin(configOptionCatalogKey{shortname: bundle.Shortname, profileName: profileName}, catalog)
+	}
+}
+
+// LoadCatalogBundle loads a bundle written by CatalogBundle.Marshal
+func LoadCatalogBundle(data []byte) (*CatalogBundle, error) {
+	bundle := &CatalogBundle{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return nil, err
+	}
+	if bundle.Version != CatalogBundleVersion {
+		return nil, fmt.Errorf("unsupported catalog bundle version %d, expected %d", bundle.Version, CatalogBundleVersion)
+	}
+	return bundle, nil
+}
+
+// Marshal writes the bundle as indented JSON, with the options of every profile sorted by name so that bundles of
+// unchanged catalogs differ only in CreatedAt. Use DiffCatalogBundles to compare bundles.
+func (b *CatalogBundle) Marshal() ([]byte, error) {
+	sorted := *b
+	sorted.Profiles = map[string][]ConfigOption{}
+	for profileName, configOptions := range b.Profiles {
+		sorted.Profiles[profileName] = sortedConfigOptions(configOptions)
+	}
+	data, err := json.MarshalIndent(sorted, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ProfileNames returns the sorted names of the profiles in the bundle
+func (b *CatalogBundle) ProfileNames() []string {
+	profileNames := make([]string, 0, len(b.Profiles))
+	for profileName := range b.Profiles {
+		profileNames = append(profileNames, profileName)
+	}
+	sort.Strings(profileNames)
+	return profileNames
+}
+
+// Catalog returns the catalog of the said profile
+func (b *CatalogBundle) Catalog(profileName string) (*ConfigOptionCatalog, bool) {
+	configOptions, ok := b.Profiles[profileName]
+	if !ok {
+		return nil, false
+	}
+	return NewConfigOptionCatalog(profileName, configOptions), true
+}
+
+// Validate validates the body against the catalog of its service profile in the bundle
+func (b *CatalogBundle) Validate(body *DeliveryServiceInstanceBody) error {
+	catalog, ok := b.Catalog(body.ServiceProfileName)
+	if !ok {
+		return fmt.Errorf("catalog bundle has no service profile %q", body.ServiceProfileName)
+	}
+	return catalog.Validate(body)
+}
+
+// WriteMarkdown writes reference documentation listing every option of every profile with its arguments
+func (b *CatalogBundle) WriteMarkdown(w io.Writer) error {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# Configuration options for %s\n", b.Shortname)
+	for _, profile := range b.documentationProfiles() {
+		fmt.Fprintf(&builder, "\n## %s\n", profile.Name)
+		for _, option := range profile.Options {
+			fmt.Fprintf(&builder, "\n### %s\n\n", option.Name)
+			if option.Details.Description != "" {
+				fmt.Fprintf(&builder, "%s\n\n", option.Details.Description)
+			}
+			if len(option.Details.Arguments) == 0 {
+				builder.WriteString("Takes no arguments.\n")
+				continue
+			}
+			builder.WriteString("| # | Name | Type | Required | Constraints | Description |\n")
+			builder.WriteString("|---|------|------|----------|-------------|-------------|\n")
+			for i, argument := range option.Details.Arguments {
+				fmt.Fprintf(&builder, "| %d | %s | %s | %s | %s | %s |\n", i+1, markdownCell(argument.Name), markdownCell(argument.Type),
+					argumentRequirement(option.Details, i), markdownCell(argumentConstraints(argument)), markdownCell(argument.Description))
+			}
+		}
+	}
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+var catalogHTMLTemplate = template.Must(template.New("catalog").Funcs(template.FuncMap{
+	"requirement": argumentRequirement,
+	"constraints": argumentConstraints,
+	"inc":         func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Configuration options for {{.Shortname}}</title></head>
+<body>
+<h1>Configuration options for {{.Shortname}}</h1>
+{{range .Profiles}}<h2>{{.Name}}</h2>
+{{range .Options}}<h3>{{.Name}}</h3>
+{{with .Details.Description}}<p>{{.}}</p>
+{{end}}{{$details := .Details}}{{if .Details.Arguments}}<table>
+<tr><th>#</th><th>Name</th><th>Type</th><th>Required</th><th>Constraints</th><th>Description</th></tr>
+{{range $i, $argument := .Details.Arguments}}<tr><td>{{inc $i}}</td><td>{{$argument.Name}}</td><td>{{$argument.Type}}</td><td>{{requirement $details $i}}</td><td>{{constraints $argument}}</td><td>{{$argument.Description}}</td></tr>
+{{end}}</table>
+{{else}}<p>Takes no arguments.</p>
+{{end}}{{end}}{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes the same reference documentation as WriteMarkdown as a standalone HTML page
+func (b *CatalogBundle) WriteHTML(w io.Writer) error {
+	return catalogHTMLTemplate.Execute(w, struct {
+		Shortname string
+		Profiles  []catalogDocumentationProfile
+	}{
+		Shortname: b.Shortname,
+		Profiles:  b.documentationProfiles(),
+	})
+}
+
+// DiffCatalogBundles lists the options added, removed or changed in every profile between two bundles. A profile
+// missing from one bundle has all of its options added or removed.
+func DiffCatalogBundles(old *CatalogBundle, new *CatalogBundle) []CatalogChange {
+	profileNames := map[string]bool{}
+	for profileName := range old.Profiles {
+		profileNames[profileName] = true
+	}
+	for profileName := range new.Profiles {
+		profileNames[profileName] = true
+	}
+
+	var changes []CatalogChange
+	for profileName := range profileNames {
+		oldOptions := configOptionsByName(old.Profiles[profileName])
+		newOptions := configOptionsByName(new.Profiles[profileName])
+		for name, oldOption := range oldOptions {
+			newOption, ok := newOptions[name]
+			if !ok {
+				changes = append(changes, CatalogChange{Profile: profileName, Option: name, Kind: CatalogChangeRemoved})
+			} else if !reflect.DeepEqual(oldOption, newOption) {
+				changes = append(changes, CatalogChange{Profile: profileName, Option: name, Kind: CatalogChangeChanged})
+			}
+		}
+		for name := range newOptions {
+			if _, ok := oldOptions[name]; !ok {
+				changes = append(changes, CatalogChange{Profile: profileName, Option: name, Kind: CatalogChangeAdded})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Profile != changes[j].Profile {
+			return changes[i].Profile < changes[j].Profile
+		}
+		return changes[i].Option < changes[j].Option
+	})
+	return changes
+}
+
+type catalogDocumentationProfile struct {
+	Name    string
+	Options []ConfigOptionBody
+}
+
+func (b *CatalogBundle) documentationProfiles() []catalogDocumentationProfile {
+	var profiles []catalogDocumentationProfile
+	for _, profileName := range b.ProfileNames() {
+		profile := catalogDocumentationProfile{Name: profileName}
+		for _, option := range sortedConfigOptions(b.Profiles[profileName]) {
+			profile.Options = append(profile.Options, option.Body)
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
+
+func sortedConfigOptions(configOptions []ConfigOption) []ConfigOption {
+	sorted := append([]ConfigOption(nil), configOptions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Body.Name < sorted[j].Body.Name
+	})
+	return sorted
+}
+
+func configOptionsByName(configOptions []ConfigOption) map[string]ConfigOptionBody {
+	byName := map[string]ConfigOptionBody{}
+	for _, option := range configOptions {
+		byName[option.Body.Name] = option.Body
+	}
+	return byName
+}
+
+func argumentRequirement(details ConfigOptionDetails, position int) string {
+	requirement := "yes"
+	if details.Arguments[position].Optional {
+		requirement = "no"
+	}
+	if details.Repeatable && position == len(details.Arguments)-1 {
+		requirement += ", repeatable"
+	}
+	return requirement
+}
+
+func argumentConstraints(argument ConfigOptionArgument) string {
+	var constraints []string
+	if len(argument.Values) > 0 {
+		constraints = append(constraints, "one of "+strings.Join(argument.Values, ", "))
+	}
+	if argument.Min != nil {
+		constraints = append(constraints, fmt.Sprintf("min %v", *argument.Min))
+	}
+	if argument.Max != nil {
+		constraints = append(constraints, fmt.Sprintf("max %v", *argument.Max))
+	}
+	return strings.Join(constraints, "; ")
+}
+
+func markdownCell(text string) string {
+	return strings.Replace(strings.Replace(text, "|", "\\|", -1), "\n", " ", -1)
+}
@@ -0,0 +1,130 @@
+package configuration
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultConfigOptionCatalogTTL is how long a cached config option catalog is used before it is fetched again
+const DefaultConfigOptionCatalogTTL = time.Hour
+
+type configOptionCatalogKey struct {
+	shortname   string
+	profileName string
+}
+
+type configOptionCatalogEntry struct {
+	catalog *ConfigOptionCatalog
+	expires time.Time
+}
+
+// configOptionCatalogCall is an in-flight fetch that concurrent callers for the same key wait on
+type configOptionCatalogCall struct {
+	done    chan struct{}
+	catalog *ConfigOptionCatalog
+	err     error
+}
+
+// configOptionCatalogCache caches catalogs per shortname and service profile. The lock is never held while a
+// catalog is being fetched.
+type configOptionCatalogCache struct {
+	lock    sync.Mutex
+	ttl     time.Duration
+	entries map[configOptionCatalogKey]configOptionCatalogEntry
+	calls   map[configOptionCatalogKey]*configOptionCatalogCall
+}
+
+func newConfigOptionCatalogCache(ttl time.Duration) *configOptionCatalogCache {
+	return &configOptionCatalogCache{
+		ttl:     ttl,
+		entries: map[configOptionCatalogKey]configOptionCatalogEntry{},
+		calls:   map[configOptionCatalogKey]*configOptionCatalogCall{},
+	}
+}
+
+func (cache *configOptionCatalogCache) get(key configOptionCatalogKey, fetch func() (*ConfigOptionCatalog, error)) (*ConfigOptionCatalog, error) {
+	cache.lock.Lock()
+	if entry, ok := cache.entries[key]; ok && (entry.expires.IsZero() || time.Now().Before(entry.expires)) {
+		cache.lock.Unlock()
+		return entry.catalog, nil
+	}
+	if call, ok := cache.calls[key]; ok {
+		cache.lock.Unlock()
+		<-call.done
+		return call.catalog, call.err
+	}
+	call := &configOptionCatalogCall{done: make(chan struct{})}
+	cache.calls[key] = call
+	cache.lock.Unlock()
+
+	call.catalog, call.err = fetch()
+
+	cache.lock.Lock()
+	delete(cache.calls, key)
+	if call.err == nil {
+		cache.setLocked(key, call.catalog)
+	}
+	cache.lock.Unlock()
+	close(call.done)
+
+	return call.catalog, call.err
+}
+
+// pin caches the catalog until it is invalidated, regardless of the ttl
+func (cache *configOptionCatalogCache) pin(key configOptionCatalogKey, catalog *ConfigOptionCatalog) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.entries[key] = configOptionCatalogEntry{catalog: catalog}
+}
+
+func (cache *configOptionCatalogCache) setLocked(key configOptionCatalogKey, catalog *ConfigOptionCatalog) {
+	entry := configOptionCatalogEntry{catalog: catalog}
+	if cache.ttl > 0 {
+		entry.expires = time.Now().Add(cache.ttl)
+	}
+	cache.entries[key] = entry
+}
+
+func (cache *configOptionCatalogCache) setTTL(ttl time.Duration) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.ttl = ttl
+}
+
+func (cache *configOptionCatalogCache) invalidate(key configOptionCatalogKey) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	delete(cache.entries, key)
+}
+
+func (cache *configOptionCatalogCache) invalidateAll() {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.entries = map[configOptionCatalogKey]configOptionCatalogEntry{}
+}
+
+// CachedConfigOptionCatalog returns the catalog for the said shortname and service profile, fetching it only if it
+// is not already cached or has expired. Concurrent callers asking for the same catalog share a single fetch.
+func (c *ConfigurationClient) CachedConfigOptionCatalog(shortname string, profileName string) (*ConfigOptionCatalog, error) {
+	key := configOptionCatalogKey{shortname: shortname, profileName: profileName}
+	return c.configOptionCatalogs.get(key, func() (*ConfigOptionCatalog, error) {
+		catalog, _, err := c.GetConfigOptionCatalog(shortname, profileName)
+		return catalog, err
+	})
+}
+
+// SetConfigOptionCatalogTTL sets how long cached catalogs are used. A ttl of zero or less caches catalogs until they
+// are invalidated.
+func (c *ConfigurationClient) SetConfigOptionCatalogTTL(ttl time.Duration) {
+	c.configOptionCatalogs.setTTL(ttl)
+}
+
+// InvalidateConfigOptionCatalog drops the cached catalog for the said shortname and service profile
+func (c *ConfigurationClient) InvalidateConfigOptionCatalog(shortname string, profileName string) {
+	c.configOptionCatalogs.invalidate(configOptionCatalogKey{shortname: shortname, profileName: profileName})
+}
+
+// InvalidateConfigOptionCatalogs drops every cached catalog
+func (c *ConfigurationClient) InvalidateConfigOptionCatalogs() {
+	c.configOptionCatalogs.invalidateAll()
+}
@@ -0,0 +1,273 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	ArgumentTypeInt    = "Int"
+	ArgumentTypeFloat  = "Float"
+	ArgumentTypeBool   = "Boolean"
+	ArgumentTypeString = "String"
+	ArgumentTypeList   = "List"
+)
+
+// ConfigOptionCatalog indexes the config options available to a service profile by option name
+type ConfigOptionCatalog struct {
+	ProfileName string
+	options     map[string]ConfigOptionBody
+}
+
+// ValidationError describes a single problem found in a DeliveryServiceInstanceBody
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors collects every ValidationError found in a DeliveryServiceInstanceBody
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, validationError := range e {
+		messages[i] = validationError.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// NewConfigOptionCatalog builds a catalog from the options returned by GetConfigurationOptions
+func NewConfigOptionCatalog(profileName string, configOptions []ConfigOption) *ConfigOptionCatalog {
+	catalog := &ConfigOptionCatalog{
+		ProfileName: profileName,
+		options:     map[string]ConfigOptionBody{},
+	}
+	for _, option := range configOptions {
+		catalog.options[option.Body.Name] = option.Body
+	}
+	return catalog
+}
+
+// Option returns the schema of the said option
+func (cat *ConfigOptionCatalog) Option(name string) (ConfigOptionBody, bool) {
+	option, ok := cat.options[name]
+	return option, ok
+}
+
+// Names returns the sorted names of every option in the catalog
+func (cat *ConfigOptionCatalog) Names() []string {
+	names := make([]string, 0, len(cat.options))
+	for name := range cat.options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ArgumentCount returns the minimum and maximum number of parameters accepted by the option. A maximum of -1
+// means the last argument may be repeated without limit.
+func (d ConfigOptionDetails) ArgumentCount() (int, int) {
+	min := 0
+	for i, argument := range d.Arguments {
+		if !argument.Optional {
+			min = i + 1
+		}
+	}
+	if d.Repeatable && len(d.Arguments) > 0 {
+		return min, -1
+	}
+	return min, len(d.Arguments)
+}
+
+// Argument returns the schema of the parameter at the said position, accounting for a repeatable last argument
+func (d ConfigOptionDetails) Argument(position int) (ConfigOptionArgument, bool) {
+	if position < 0 || len(d.Arguments) == 0 {
+		return ConfigOptionArgument{}, false
+	}
+	if position >= len(d.Arguments) {
+		if !d.Repeatable {
+			return ConfigOptionArgument{}, false
+		}
+		position = len(d.Arguments) - 1
+	}
+	return d.Arguments[position], true
+}
+
+// Validate checks every option of the body against the catalog, returning ValidationErrors listing each unknown
+// option name, wrong argument count and wrongly typed or out of range parameter, or nil if the body is valid
+func (cat *ConfigOptionCatalog) Validate(body *DeliveryServiceInstanceBody) error {
+	var validationErrors ValidationErrors
+	for i, protocolSet := range body.ProtocolSets {
+		for j, option := range protocolSet.Options {
+			path := fmt.Sprintf("protocolSets[%d].options[%d]", i, j)
+			validationErrors = append(validationErrors, cat.validateOption(path, option)...)
+		}
+	}
+
+	if len(validationErrors) == 0 {
+		return nil
+	}
+	return validationErrors
+}
+
+func (cat *ConfigOptionCatalog) validateOption(path string, option Option) ValidationErrors {
+	schema, ok := cat.options[option.Name]
+	if !ok {
+		return ValidationErrors{{Path: path + ".name", Message: fmt.Sprintf("unknown option %q for service profile %q", option.Name, cat.ProfileName)}}
+	}
+
+	var validationErrors ValidationErrors
+	min, max := schema.Details.ArgumentCount()
+	if len(option.Parameters) < min || (max >= 0 && len(option.Parameters) > max) {
+		expected := fmt.Sprintf("%d", min)
+		if max < 0 {
+			expected = fmt.Sprintf("at least %d", min)
+		} else if max != min {
+			expected = fmt.Sprintf("%d to %d", min, max)
+		}
+		validationErrors = append(validationErrors, ValidationError{
+			Path:    path + ".parameters",
+			Message: fmt.Sprintf("option %q takes %s parameters, got %d", option.Name, expected, len(option.Parameters)),
+		})
+	}
+
+	for k, parameter := range option.Parameters {
+		argument, ok := schema.Details.Argument(k)
+		if !ok {
+			continue
+		}
+		if message := validateParameter(argument, parameter); message != "" {
+			validationErrors = append(validationErrors, ValidationError{
+				Path:    fmt.Sprintf("%s.parameters[%d]", path, k),
+				Message: message,
+			})
+		}
+	}
+	return validationErrors
+}
+
+func validateParameter(argument ConfigOptionArgument, parameter interface{}) string {
+	switch normalizeArgumentType(argument.Type) {
+	case ArgumentTypeInt:
+		number, ok := parameterNumber(parameter)
+		if !ok || number != math.Trunc(number) {
+			return fmt.Sprintf("expected %s, got %s", ArgumentTypeInt, describeParameter(parameter))
+		}
+		if message := validateRange(argument, number); message != "" {
+			return message
+		}
+	case ArgumentTypeFloat:
+		number, ok := parameterNumber(parameter)
+		if !ok {
+			return fmt.Sprintf("expected %s, got %s", ArgumentTypeFloat, describeParameter(parameter))
+		}
+		if message := validateRange(argument, number); message != "" {
+			return message
+		}
+	case ArgumentTypeBool:
+		if _, ok := parameter.(bool); !ok {
+			return fmt.Sprintf("expected %s, got %s", ArgumentTypeBool, describeParameter(parameter))
+		}
+	case ArgumentTypeString:
+		if _, ok := parameter.(string); !ok {
+			return fmt.Sprintf("expected %s, got %s", ArgumentTypeString, describeParameter(parameter))
+		}
+	case ArgumentTypeList:
+		switch parameter.(type) {
+		case []interface{}, []string:
+		default:
+			return fmt.Sprintf("expected %s, got %s", ArgumentTypeList, describeParameter(parameter))
+		}
+	}
+
+	if len(argument.Values) > 0 {
+		value := fmt.Sprint(parameter)
+		for _, allowed := range argument.Values {
+			if value == allowed {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%q is not one of %s", value, strings.Join(argument.Values, ", "))
+	}
+	return ""
+}
+
+func validateRange(argument ConfigOptionArgument, number float64) string {
+	if argument.Min != nil && number < *argument.Min {
+		return fmt.Sprintf("%v is less than the minimum of %v", number, *argument.Min)
+	}
+	if argument.Max != nil && number > *argument.Max {
+		return fmt.Sprintf("%v is greater than the maximum of %v", number, *argument.Max)
+	}
+	return ""
+}
+
+// normalizeArgumentType maps the type names used by the API onto the ArgumentType constants. Unrecognised types are
+// returned unchanged and are not checked.
+func normalizeArgumentType(argumentType string) string {
+	switch strings.ToLower(argumentType) {
+	case "int", "integer", "long":
+		return ArgumentTypeInt
+	case "float", "double", "decimal", "number":
+		return ArgumentTypeFloat
+	case "bool", "boolean":
+		return ArgumentTypeBool
+	case "string", "str", "text":
+		return ArgumentTypeString
+	case "list", "array":
+		return ArgumentTypeList
+	}
+	return argumentType
+}
+
+func parameterNumber(parameter interface{}) (float64, bool) {
+	switch value := parameter.(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case int32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case json.Number:
+		number, err := value.Float64()
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func describeParameter(parameter interface{}) string {
+	if parameter == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%T %v", parameter, parameter)
+}
+
+// GetConfigOptionCatalog fetches the config options available to the said service profile as a catalog
+func (c *ConfigurationClient) GetConfigOptionCatalog(shortname string, profileName string) (*ConfigOptionCatalog, *http.Response, error) {
+	configOptions, response, err := c.GetConfigurationOptions(shortname, profileName)
+	if err != nil {
+		return nil, response, err
+	}
+	return NewConfigOptionCatalog(profileName, configOptions), response, nil
+}
+
+// ValidateDeliveryServiceInstanceBody validates the body against the catalog of its service profile
+func (c *ConfigurationClient) ValidateDeliveryServiceInstanceBody(shortname string, body *DeliveryServiceInstanceBody) error {
+	catalog, err := c.CachedConfigOptionCatalog(shortname, body.ServiceProfileName)
+	if err != nil {
+		return err
+	}
+	return catalog.Validate(body)
+}
@@ -0,0 +1,75 @@
+package configuration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testCatalog() *ConfigOptionCatalog {
+	min, max := 0.0, 86400.0
+	return NewConfigOptionCatalog("Test", []ConfigOption{
+		{Body: ConfigOptionBody{Name: "refresh_absmin", Details: ConfigOptionDetails{Arguments: []ConfigOptionArgument{
+			{Name: "seconds", Type: "integer", Min: &min, Max: &max},
+		}}}},
+		{Body: ConfigOptionBody{Name: "compress", Details: ConfigOptionDetails{Arguments: []ConfigOptionArgument{
+			{Name: "enabled", Type: "Boolean"},
+			{Name: "level", Type: "Float", Optional: true},
+		}}}},
+		{Body: ConfigOptionBody{Name: "req_send_header", Details: ConfigOptionDetails{Repeatable: true, Arguments: []ConfigOptionArgument{
+			{Name: "name", Type: "String"},
+			{Name: "value", Type: "String"},
+		}}}},
+		{Body: ConfigOptionBody{Name: "cors_methods", Details: ConfigOptionDetails{Arguments: []ConfigOptionArgument{
+			{Name: "method", Type: "String", Values: []string{"GET", "POST"}},
+		}}}},
+		{Body: ConfigOptionBody{Name: "hosts", Details: ConfigOptionDetails{Arguments: []ConfigOptionArgument{
+			{Name: "hosts", Type: "List"},
+		}}}},
+	})
+}
+
+func TestConfigOptionCatalogValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		option Option
+		want   []string
+	}{
+		{name: "valid integer", option: Option{Name: "refresh_absmin", Parameters: []interface{}{3600.0}}},
+		{name: "fractional integer", option: Option{Name: "refresh_absmin", Parameters: []interface{}{1.5}}, want: []string{"protocolSets[0].options[0].parameters[0]"}},
+		{name: "integer above maximum", option: Option{Name: "refresh_absmin", Parameters: []interface{}{90000}}, want: []string{"protocolSets[0].options[0].parameters[0]"}},
+		{name: "integer below minimum", option: Option{Name: "refresh_absmin", Parameters: []interface{}{-1}}, want: []string{"protocolSets[0].options[0].parameters[0]"}},
+		{name: "integer as string", option: Option{Name: "refresh_absmin", Parameters: []interface{}{"60"}}, want: []string{"protocolSets[0].options[0].parameters[0]"}},
+		{name: "missing parameter", option: Option{Name: "refresh_absmin"}, want: []string{"protocolSets[0].options[0].parameters"}},
+		{name: "optional parameter omitted", option: Option{Name: "compress", Parameters: []interface{}{true}}},
+		{name: "optional parameter given", option: Option{Name: "compress", Parameters: []interface{}{true, 6.5}}},
+		{name: "too many parameters", option: Option{Name: "compress", Parameters: []interface{}{true, 6.5, 1.0}}, want: []string{"protocolSets[0].options[0].parameters"}},
+		{name: "wrong boolean", option: Option{Name: "compress", Parameters: []interface{}{"yes"}}, want: []string{"protocolSets[0].options[0].parameters[0]"}},
+		{name: "repeated arguments", option: Option{Name: "req_send_header", Parameters: []interface{}{"X-A", "1", "X-B", "2"}}},
+		{name: "repeated argument of wrong type", option: Option{Name: "req_send_header", Parameters: []interface{}{"X-A", 1.0}}, want: []string{"protocolSets[0].options[0].parameters[1]"}},
+		{name: "allowed value", option: Option{Name: "cors_methods", Parameters: []interface{}{"GET"}}},
+		{name: "disallowed value", option: Option{Name: "cors_methods", Parameters: []interface{}{"PATCH"}}, want: []string{"protocolSets[0].options[0].parameters[0]"}},
+		{name: "list", option: Option{Name: "hosts", Parameters: []interface{}{[]interface{}{"a", "b"}}}},
+		{name: "not a list", option: Option{Name: "hosts", Parameters: []interface{}{"a"}}, want: []string{"protocolSets[0].options[0].parameters[0]"}},
+		{name: "unknown option", option: Option{Name: "unknown"}, want: []string{"protocolSets[0].options[0].name"}},
+	}
+	catalog := testCatalog()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body := &DeliveryServiceInstanceBody{ProtocolSets: []ProtocolSet{{Options: []Option{test.option}}}}
+			err := catalog.Validate(body)
+			var got []string
+			if err != nil {
+				validationErrors, ok := err.(ValidationErrors)
+				if !ok {
+					t.Fatalf("Validate() returned %T, want ValidationErrors", err)
+				}
+				for _, validationError := range validationErrors {
+					got = append(got, validationError.Path)
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Validate() error paths = %v, want %v (%v)", got, test.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// CoerceParameter converts a parameter of the said option to the type declared for its position in the catalog.
+// Parameters of unknown options, or of arguments with an unrecognised type, are returned unchanged.
+func (cat *ConfigOptionCatalog) CoerceParameter(optionName string, position int, parameter interface{}) (interface{}, error) {
+	schema, ok := cat.options[optionName]
+	if !ok {
+		return parameter, nil
+	}
+	argument, ok := schema.Details.Argument(position)
+	if !ok {
+		return parameter, nil
+	}
+	return coerceParameter(normalizeArgumentType(argument.Type), parameter)
+}
+
+// CoerceOption returns a copy of the option with every parameter converted to its declared type
+func (cat *ConfigOptionCatalog) CoerceOption(option Option) (Option, error) {
+	if option.Parameters == nil {
+		return option, nil
+	}
+
+	coerced := Option{
+		Name:       option.Name,
+		Parameters: make([]interface{}, len(option.Parameters)),
+	}
+	for i, parameter := range option.Parameters {
+		value, err := cat.CoerceParameter(option.Name, i, parameter)
+		if err != nil {
+			return option, err
+		}
+		coerced.Parameters[i] = value
+	}
+	return coerced, nil
+}
+
+// CoerceBody converts the parameters of every option in the body to their declared types in place. Parameters that
+// cannot be converted are left as they were and reported as ValidationErrors.
+func (cat *ConfigOptionCatalog) CoerceBody(body *DeliveryServiceInstanceBody) error {
+	var validationErrors ValidationErrors
+	for i := range body.ProtocolSets {
+		options := body.ProtocolSets[i].Options
+		for j := range options {
+			for k, parameter := range options[j].Parameters {
+				value, err := cat.CoerceParameter(options[j].Name, k, parameter)
+				if err != nil {
+					validationErrors = append(validationErrors, ValidationError{
+						Path:    fmt.Sprintf("protocolSets[%d].options[%d].parameters[%d]", i, j, k),
+						Message: err.Error(),
+					})
+					continue
+				}
+				options[j].Parameters[k] = value
+			}
+		}
+	}
+
+	if len(validationErrors) == 0 {
+		return nil
+	}
+	return validationErrors
+}
+
+func coerceParameter(argumentType string, parameter interface{}) (interface{}, error) {
+	switch argumentType {
+	case ArgumentTypeInt:
+		if text, ok := parameter.(string); ok {
+			value, err := strconv.Atoi(text)
+			if err != nil {
+				return parameter, fmt.Errorf("cannot convert %q to %s", text, ArgumentTypeInt)
+			}
+			return value, nil
+		}
+		number, ok := parameterNumber(parameter)
+		if !ok || number != math.Trunc(number) {
+			return parameter, fmt.Errorf("cannot convert %s to %s", describeParameter(parameter), ArgumentTypeInt)
+		}
+		return int(number), nil
+	case ArgumentTypeFloat:
+		if text, ok := parameter.(string); ok {
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return parameter, fmt.Errorf("cannot convert %q to %s", text, ArgumentTypeFloat)
+			}
+			return value, nil
+		}
+		number, ok := parameterNumber(parameter)
+		if !ok {
+			return parameter, fmt.Errorf("cannot convert %s to %s", describeParameter(parameter), ArgumentTypeFloat)
+		}
+		return number, nil
+	case ArgumentTypeBool:
+		switch value := parameter.(type) {
+		case bool:
+			return value, nil
+		case string:
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return parameter, fmt.Errorf("cannot convert %q to %s", value, ArgumentTypeBool)
+			}
+			return parsed, nil
+		}
+		return parameter, fmt.Errorf("cannot convert %s to %s", describeParameter(parameter), ArgumentTypeBool)
+	case ArgumentTypeString:
+		switch value := parameter.(type) {
+		case string:
+			return value, nil
+		case bool:
+			return strconv.FormatBool(value), nil
+		case json.Number:
+			return value.String(), nil
+		}
+		if number, ok := parameterNumber(parameter); ok {
+			return strconv.FormatFloat(number, 'f', -1, 64), nil
+		}
+		return parameter, fmt.Errorf("cannot convert %s to %s", describeParameter(parameter), ArgumentTypeString)
+	case ArgumentTypeList:
+		switch value := parameter.(type) {
+		case []interface{}:
+			return value, nil
+		case []string:
+			list := make([]interface{}, len(value))
+			for i, item := range value {
+				list[i] = item
+			}
+			return list, nil
+		}
+		return parameter, fmt.Errorf("cannot convert %s to %s", describeParameter(parameter), ArgumentTypeList)
+	}
+	return parameter, nil
+}
+
+// CopyDeliveryServiceInstanceBody copies the body, including the lists and maps nested in option parameters, so that
+// it can be modified without affecting the original
+func CopyDeliveryServiceInstanceBody(body *DeliveryServiceInstanceBody) *DeliveryServiceInstanceBody {
+	bodyCopy := *body
+	if body.ProtocolSets == nil {
+		return &bodyCopy
+	}
+
+	bodyCopy.ProtocolSets = make([]ProtocolSet, len(body.ProtocolSets))
+	for i, protocolSet := range body.ProtocolSets {
+		if protocolSet.SourcePort != nil {
+			sourcePort := *protocolSet.SourcePort
+			protocolSet.SourcePort = &sourcePort
+		}
+		if protocolSet.Options != nil {
+			options := make([]Option, len(protocolSet.Options))
+			for j, option := range protocolSet.Options {
+				if option.Parameters != nil {
+					option.Parameters = copyParameter(option.Parameters).([]interface{})
+				}
+				options[j] = option
+			}
+			protocolSet.Options = options
+		}
+		bodyCopy.ProtocolSets[i] = protocolSet
+	}
+	return &bodyCopy
+}
+
+// copyParameter copies the lists and maps of a parameter, however deeply nested
+func copyParameter(parameter interface{}) interface{} {
+	switch value := parameter.(type) {
+	case []interface{}:
+		if value == nil {
+			return value
+		}
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			list[i] = copyParameter(item)
+		}
+		return list
+	case []string:
+		if value == nil {
+			return value
+		}
+		return append([]string{}, value...)
+	case map[string]interface{}:
+		if value == nil {
+			return value
+		}
+		object := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			object[key] = copyParameter(item)
+		}
+		return object
+	}
+	return parameter
+}
+
+// coerceDeliveryServiceInstanceBody coerces the body in place when option parameter coercion is enabled
+func (c *ConfigurationClient) coerceDeliveryServiceInstanceBody(shortname string, body *DeliveryServiceInstanceBody) error {
+	if !c.coerceOptionParameters || body.ServiceProfileName == "" {
+		return nil
+	}
+	catalog, err := c.CachedConfigOptionCatalog(shortname, body.ServiceProfileName)
+	if err != nil {
+		return err
+	}
+	return catalog.CoerceBody(body)
+}
+
+// ResponseCoercionError is returned along with the instances of a response whose option parameters cannot be
+// coerced. The request itself succeeded: the instances were read or written, with those parameters left as received.
+type ResponseCoercionError struct {
+	Err error
+}
+
+func (e *ResponseCoercionError) Error() string {
+	return "coercing option parameters of the response: " + e.Err.Error()
+}
+
+// IsResponseCoercionError reports whether err only means that the response of a successful request could not be
+// coerced
+func IsResponseCoercionError(err error) bool {
+	_, ok := err.(*ResponseCoercionError)
+	return ok
+}
+
+// coerceDeliveryServiceInstance coerces an instance received in a response
+func (c *ConfigurationClient) coerceDeliveryServiceInstance(instance *DeliveryServiceInstance) error {
+	if err := c.coerceDeliveryServiceInstanceBody(owningShortname(instance), &instance.Body); err != nil {
+		return &ResponseCoercionError{Err: err}
+	}
+	return nil
+}
+
+// coerceDeliveryServiceInstances coerces every instance received in a response, reporting the parameters that cannot
+// be coerced as ValidationErrors whose paths start with the UUID of their instance
+func (c *ConfigurationClient) coerceDeliveryServiceInstances(instances []DeliveryServiceInstance) error {
+	var validationErrors ValidationErrors
+	for i := range instances {
+		err := c.coerceDeliveryServiceInstanceBody(owningShortname(&instances[i]), &instances[i].Body)
+		if instanceErrors, ok := err.(ValidationErrors); ok {
+			for _, instanceError := range instanceErrors {
+				instanceError.Path = instances[i].UUID + "." + instanceError.Path
+				validationErrors = append(validationErrors, instanceError)
+			}
+		} else if err != nil {
+			return &ResponseCoercionError{Err: err}
+		}
+	}
+	if len(validationErrors) == 0 {
+		return nil
+	}
+	return &ResponseCoercionError{Err: validationErrors}
+}
+
+func owningShortname(instance *DeliveryServiceInstance) string {
+	if instance.Shortname == "" && len(instance.Accounts) > 0 {
+		return instance.Accounts[0].Shortname
+	}
+	return instance.Shortname
+}
+
+// SetOptionParameterCoercion enables or disables converting option parameters to the types declared in the config
+// option catalog whenever delivery service instances are read or written
+func (c *ConfigurationClient) SetOptionParameterCoercion(enabled bool) {
+	c.coerceOptionParameters = enabled
+}
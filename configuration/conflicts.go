@@ -0,0 +1,154 @@
+package configuration
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ConflictDuplicate         = "duplicate"
+	ConflictShadowing         = "shadowing"
+	ConflictDuplicateProtocol = "duplicateProtocol"
+)
+
+// Conflict describes two instances publishing overlapping URLs, or a single instance publishing a protocol twice, in
+// which case Other is nil. For ConflictShadowing, Instance has the shorter PublishedURLPath and is shadowed by Other
+// for every path below Other's.
+type Conflict struct {
+	Kind     string
+	Instance *DeliveryServiceInstance
+	Other    *DeliveryServiceInstance
+	Protocol string
+}
+
+func (c Conflict) String() string {
+	switch c.Kind {
+	case ConflictDuplicate:
+		return fmt.Sprintf("%s and %s are both published at %s", describeInstance(c.Instance), describeInstance(c.Other), DeliveryServiceInstanceKeyOf(&c.Instance.Body))
+	case ConflictShadowing:
+		return fmt.Sprintf("%s is shadowed below %s by %s", describeInstance(c.Instance), DeliveryServiceInstanceKeyOf(&c.Other.Body), describeInstance(c.Other))
+	case ConflictDuplicateProtocol:
+		return fmt.Sprintf("%s publishes %s more than once", describeInstance(c.Instance), c.Protocol)
+	}
+	return c.Kind
+}
+
+// Conflicts is a list of Conflict that can be returned as an error
+type Conflicts []Conflict
+
+func (c Conflicts) Error() string {
+	messages := make([]string, len(c))
+	for i, conflict := range c {
+		messages[i] = conflict.String()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// FindConflicts reports instances published at the same hostname and URL path, whatever their protocols, as an
+// instance is identified by its DeliveryServiceInstanceKey when reconciling and restoring. It also reports shadowing
+// URL path prefixes on the same hostname and protocol, and protocols published more than once by the same instance.
+func FindConflicts(instances []DeliveryServiceInstance) Conflicts {
+	bodies := make([]*DeliveryServiceInstanceBody, len(instances))
+	for i := range instances {
+		bodies[i] = Normalize(&instances[i].Body)
+	}
+
+	var conflicts Conflicts
+	for i := range instances {
+		seen := map[string]bool{}
+		for _, protocolSet := range bodies[i].ProtocolSets {
+			if seen[protocolSet.PublishedProtocol] {
+				conflicts = append(conflicts, Conflict{Kind: ConflictDuplicateProtocol, Instance: &instances[i], Protocol: protocolSet.PublishedProtocol})
+			}
+			seen[protocolSet.PublishedProtocol] = true
+		}
+	}
+
+	for i := range instances {
+		for j := i + 1; j < len(instances); j++ {
+			a, b := bodies[i], bodies[j]
+			if a.PublishedHostname != b.PublishedHostname {
+				continue
+			}
+			if a.PublishedURLPath == b.PublishedURLPath {
+				conflicts = append(conflicts, Conflict{Kind: ConflictDuplicate, Instance: &instances[i], Other: &instances[j]})
+				continue
+			}
+			protocol, shared := sharedPublishedProtocol(a, b)
+			if !shared {
+				continue
+			}
+			if hasPathPrefix(b.PublishedURLPath, a.PublishedURLPath) {
+				conflicts = append(conflicts, Conflict{Kind: ConflictShadowing, Instance: &instances[i], Other: &instances[j], Protocol: protocol})
+			} else if hasPathPrefix(a.PublishedURLPath, b.PublishedURLPath) {
+				conflicts = append(conflicts, Conflict{Kind: ConflictShadowing, Instance: &instances[j], Other: &instances[i], Protocol: protocol})
+			}
+		}
+	}
+	return conflicts
+}
+
+// FindBodyConflicts reports the conflicts between local bodies that have not been created yet
+func FindBodyConflicts(bodies []DeliveryServiceInstanceBody) Conflicts {
+	instances := make([]DeliveryServiceInstance, len(bodies))
+	for i := range bodies {
+		instances[i].Body = bodies[i]
+	}
+	return FindConflicts(instances)
+}
+
+// FindDeliveryServiceInstanceConflicts reports the conflicts between every delivery service instance of the shortname
+func (c *ConfigurationClient) FindDeliveryServiceInstanceConflicts(shortname string) (Conflicts, error) {
+	instances, _, err := c.ListDeliveryServiceInstances(shortname)
+	if err != nil {
+		return nil, err
+	}
+	return FindConflicts(instances), nil
+}
+
+// CheckDeliveryServiceInstanceConflicts is a guard to run before creating or updating body in the shortname. It
+// returns Conflicts as an error if the body would conflict with another instance. uuid is the instance being updated,
+// which is ignored, or empty for a create.
+func (c *ConfigurationClient) CheckDeliveryServiceInstanceConflicts(shortname string, body *DeliveryServiceInstanceBody, uuid string) error {
+	live, _, err := c.ListDeliveryServiceInstances(shortname)
+	if err != nil {
+		return err
+	}
+
+	candidate := DeliveryServiceInstance{UUID: uuid, Body: *body}
+	instances := []DeliveryServiceInstance{candidate}
+	for _, instance := range live {
+		if uuid == "" || instance.UUID != uuid {
+			instances = append(instances, instance)
+		}
+	}
+
+	var conflicts Conflicts
+	for _, conflict := range FindConflicts(instances) {
+		if conflict.Instance == &instances[0] || conflict.Other == &instances[0] {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	return conflicts
+}
+
+func sharedPublishedProtocol(a *DeliveryServiceInstanceBody, b *DeliveryServiceInstanceBody) (string, bool) {
+	for _, protocolSetA := range a.ProtocolSets {
+		for _, protocolSetB := range b.ProtocolSets {
+			if protocolSetA.PublishedProtocol == protocolSetB.PublishedProtocol {
+				return protocolSetA.PublishedProtocol, true
+			}
+		}
+	}
+	return "", false
+}
+
+func describeInstance(instance *DeliveryServiceInstance) string {
+	if instance.UUID != "" {
+		return "delivery service instance " + instance.UUID
+	}
+	return "delivery service instance for " + DeliveryServiceInstanceKeyOf(&instance.Body).String()
+}
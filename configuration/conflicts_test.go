@@ -0,0 +1,109 @@
+package configuration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func publishedAt(uuid string, hostname string, path string, protocols ...string) DeliveryServiceInstance {
+	instance := DeliveryServiceInstance{UUID: uuid, Body: DeliveryServiceInstanceBody{
+		PublishedHostname: hostname,
+		PublishedURLPath:  path,
+		SourceHostname:    "origin.example.com",
+	}}
+	for _, protocol := range protocols {
+		instance.Body.ProtocolSets = append(instance.Body.ProtocolSets, ProtocolSet{PublishedProtocol: protocol, SourceProtocol: protocol})
+	}
+	return instance
+}
+
+type conflictSummary struct {
+	kind, instance, other, protocol string
+}
+
+func summarizeConflicts(conflicts Conflicts) []conflictSummary {
+	var summaries []conflictSummary
+	for _, conflict := range conflicts {
+		summary := conflictSummary{kind: conflict.Kind, instance: conflict.Instance.UUID, protocol: conflict.Protocol}
+		if conflict.Other != nil {
+			summary.other = conflict.Other.UUID
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+func TestFindConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances []DeliveryServiceInstance
+		want      []conflictSummary
+	}{
+		{
+			name: "distinct URLs",
+			instances: []DeliveryServiceInstance{
+				publishedAt("a", "www.example.com", "/a", "HTTPS"),
+				publishedAt("b", "www.example.com", "/b", "HTTPS"),
+				publishedAt("c", "cdn.example.com", "/a", "HTTPS"),
+			},
+		},
+		{
+			name: "same URL",
+			instances: []DeliveryServiceInstance{
+				publishedAt("a", "www.example.com", "/", "HTTP", "HTTPS"),
+				publishedAt("b", "WWW.EXAMPLE.COM", "/", "https"),
+			},
+			want: []conflictSummary{{kind: ConflictDuplicate, instance: "a", other: "b"}},
+		},
+		{
+			name: "same URL over different protocols",
+			instances: []DeliveryServiceInstance{
+				publishedAt("a", "www.example.com", "/", "HTTP"),
+				publishedAt("b", "www.example.com", "/", "HTTPS"),
+			},
+			want: []conflictSummary{{kind: ConflictDuplicate, instance: "a", other: "b"}},
+		},
+		{
+			name: "shorter path listed first",
+			instances: []DeliveryServiceInstance{
+				publishedAt("a", "www.example.com", "/", "HTTPS"),
+				publishedAt("b", "www.example.com", "/video", "HTTPS"),
+			},
+			want: []conflictSummary{{kind: ConflictShadowing, instance: "a", other: "b", protocol: "HTTPS"}},
+		},
+		{
+			name: "shorter path listed last",
+			instances: []DeliveryServiceInstance{
+				publishedAt("a", "www.example.com", "/video", "HTTPS"),
+				publishedAt("b", "www.example.com", "/", "HTTPS"),
+			},
+			want: []conflictSummary{{kind: ConflictShadowing, instance: "b", other: "a", protocol: "HTTPS"}},
+		},
+		{
+			name: "prefix ending inside a segment",
+			instances: []DeliveryServiceInstance{
+				publishedAt("a", "www.example.com", "/video", "HTTPS"),
+				publishedAt("b", "www.example.com", "/videos", "HTTPS"),
+			},
+		},
+		{
+			name: "prefix over different protocols",
+			instances: []DeliveryServiceInstance{
+				publishedAt("a", "www.example.com", "/", "HTTP"),
+				publishedAt("b", "www.example.com", "/video", "HTTPS"),
+			},
+		},
+		{
+			name:      "protocol published twice",
+			instances: []DeliveryServiceInstance{publishedAt("a", "www.example.com", "/", "HTTPS", "https")},
+			want:      []conflictSummary{{kind: ConflictDuplicateProtocol, instance: "a", protocol: "HTTPS"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := summarizeConflicts(FindConflicts(test.instances)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("FindConflicts() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -17,6 +17,10 @@ type DeliveryServiceInstance struct {
 	Body      DeliveryServiceInstanceBody `json:"body"`
 }
 
+type DeliveryServiceInstancesResponse struct {
+	Results []DeliveryServiceInstance `json:"results"`
+}
+
 type DeliveryServiceInstanceCreateRequest struct {
 	Body     DeliveryServiceInstanceBody `json:"body"`
 	Accounts []Account                   `json:"accounts"`
@@ -64,6 +68,17 @@ type Account struct {
 	Shortname string `json:"shortname"`
 }
 
+// DeliveryServiceInstanceFilter selects delivery service instances for bulk operations
+type DeliveryServiceInstanceFilter func(instance *DeliveryServiceInstance) bool
+
+// DeliveryServiceInstanceResult is the outcome of a bulk operation on a single delivery service instance
+type DeliveryServiceInstanceResult struct {
+	UUID     string
+	Instance *DeliveryServiceInstance
+	Response *http.Response
+	Err      error
+}
+
 // End - DeliveryServiceInstance types
 
 // Start - ConfigOption types
@@ -81,11 +96,19 @@ type ConfigOptionBody struct {
 }
 
 type ConfigOptionDetails struct {
-	Arguments []ConfigOptionArgument `json:"argumentList"`
+	Description string                 `json:"description,omitempty"`
+	Arguments   []ConfigOptionArgument `json:"argumentList"`
+	Repeatable  bool                   `json:"repeatable,omitempty"`
 }
 
 type ConfigOptionArgument struct {
-	Type string `json:"type"`
+	Name        string   `json:"name,omitempty"`
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Optional    bool     `json:"optional,omitempty"`
+	Values      []string `json:"enumValues,omitempty"`
+	Min         *float64 `json:"minValue,omitempty"`
+	Max         *float64 `json:"maxValue,omitempty"`
 }
 
 // End - ConfigOption types
@@ -105,36 +128,24 @@ func (c *ConfigurationClient) GetConfigurationOptions(shortname string, profileN
 }
 
 func (c *ConfigurationClient) IsOptionArgumentInteger(shortname string, profileName string, optionName string, argumentPosition int) (bool, error) {
-	c.configOptionLock.Lock()
-	defer c.configOptionLock.Unlock()
-
-	if c.configOptionArgumentIntegerCache == nil {
-		configOptions, _, err := c.GetConfigurationOptions(shortname, profileName)
-		if err != nil {
-			return false, err
-		}
-		optionsMap := map[string][]bool{}
-		for _, option := range configOptions {
-			var argumentIntegerList []bool
-			for _, argument := range option.Body.Details.Arguments {
-				argumentIntegerList = append(argumentIntegerList, argument.Type == "Int")
-			}
-			optionsMap[option.Body.Name] = argumentIntegerList
-		}
-		c.configOptionArgumentIntegerCache = optionsMap
+	catalog, err := c.CachedConfigOptionCatalog(shortname, profileName)
+	if err != nil {
+		return false, err
 	}
 
-	if argumentIntegerList, ok := c.configOptionArgumentIntegerCache[optionName]; ok {
-		if argumentPosition >= len(argumentIntegerList) {
+	if option, ok := catalog.Option(optionName); ok {
+		if argumentPosition < 0 || argumentPosition >= len(option.Details.Arguments) {
 			return false, nil
 		} else {
-			return argumentIntegerList[argumentPosition], nil
+			return normalizeArgumentType(option.Details.Arguments[argumentPosition].Type) == ArgumentTypeInt, nil
 		}
 	} else {
 		return false, nil
 	}
 }
 
+// GetDeliveryServiceInstance fetches the said instance. When parameter coercion is enabled and some parameters cannot
+// be coerced, the instance is returned along with a ResponseCoercionError, with those parameters left as received.
 func (c *ConfigurationClient) GetDeliveryServiceInstance(uuid string) (*DeliveryServiceInstance, *http.Response, error) {
 	<-c.rateLimiter
 	deliveryServiceInstance := &DeliveryServiceInstance{}
@@ -147,18 +158,36 @@ func (c *ConfigurationClient) GetDeliveryServiceInstance(uuid string) (*Delivery
 
 	json.Unmarshal(body, deliveryServiceInstance)
 
-	return deliveryServiceInstance, response, nil
+	err = c.coerceDeliveryServiceInstance(deliveryServiceInstance)
+
+	return deliveryServiceInstance, response, err
 }
 
 func (c *ConfigurationClient) CreateDeliveryServiceInstance(body *DeliveryServiceInstanceBody, shortname string) (*DeliveryServiceInstance, *http.Response, error) {
+	return c.CreateDeliveryServiceInstanceWithAccounts(body, []Account{
+		Account{
+			Shortname: shortname,
+		},
+	})
+}
+
+// CreateDeliveryServiceInstanceWithAccounts creates an instance owned by every one of the said accounts. If the
+// instance was created but its response cannot be coerced, the created instance is returned along with a
+// ResponseCoercionError.
+func (c *ConfigurationClient) CreateDeliveryServiceInstanceWithAccounts(body *DeliveryServiceInstanceBody, accounts []Account) (*DeliveryServiceInstance, *http.Response, error) {
+	if len(accounts) == 0 {
+		return nil, nil, fmt.Errorf("a delivery service instance must be owned by at least one account")
+	}
+
+	body = CopyDeliveryServiceInstanceBody(body)
+	if err := c.coerceDeliveryServiceInstanceBody(accounts[0].Shortname, body); err != nil {
+		return nil, nil, err
+	}
+
 	<-c.rateLimiter
 	request := &DeliveryServiceInstanceCreateRequest{
-		Body: *body,
-		Accounts: []Account{
-			Account{
-				Shortname: shortname,
-			},
-		},
+		Body:     *body,
+		Accounts: accounts,
 	}
 
 	jsonRequest, _ := json.Marshal(request)
@@ -172,19 +201,41 @@ func (c *ConfigurationClient) CreateDeliveryServiceInstance(body *DeliveryServic
 	deliveryServiceInstance := &DeliveryServiceInstance{}
 	json.Unmarshal(respBody, deliveryServiceInstance)
 
-	return deliveryServiceInstance, response, nil
+	err = c.coerceDeliveryServiceInstance(deliveryServiceInstance)
+
+	return deliveryServiceInstance, response, err
 }
 
+// UpdateDeliveryServiceInstance updates the body of the said instance. The instance is fetched first so that it keeps
+// every account owning it, with shortname added to them if it was not one already. To replace the owners, use
+// UpdateDeliveryServiceInstanceWithAccounts instead.
 func (c *ConfigurationClient) UpdateDeliveryServiceInstance(uuid string, body *DeliveryServiceInstanceBody, shortname string) (*DeliveryServiceInstance, *http.Response, error) {
+	current, response, err := c.GetDeliveryServiceInstance(uuid)
+	if err != nil && !IsResponseCoercionError(err) {
+		return nil, response, err
+	}
+
+	return c.UpdateDeliveryServiceInstanceWithAccounts(uuid, body, mergeAccounts(current.Accounts, Account{Shortname: shortname}))
+}
+
+// UpdateDeliveryServiceInstanceWithAccounts updates the body of the said instance and replaces its owners with
+// exactly the said accounts. If the instance was updated but its response cannot be coerced, the updated instance is
+// returned along with a ResponseCoercionError.
+func (c *ConfigurationClient) UpdateDeliveryServiceInstanceWithAccounts(uuid string, body *DeliveryServiceInstanceBody, accounts []Account) (*DeliveryServiceInstance, *http.Response, error) {
+	if len(accounts) == 0 {
+		return nil, nil, fmt.Errorf("a delivery service instance must be owned by at least one account")
+	}
+
+	body = CopyDeliveryServiceInstanceBody(body)
+	if err := c.coerceDeliveryServiceInstanceBody(accounts[0].Shortname, body); err != nil {
+		return nil, nil, err
+	}
+
 	<-c.rateLimiter
 	request := &DeliveryServiceInstanceUpdateRequest{
-		UUID: uuid,
-		Body: *body,
-		Accounts: []Account{
-			Account{
-				Shortname: shortname,
-			},
-		},
+		UUID:     uuid,
+		Body:     *body,
+		Accounts: accounts,
 	}
 
 	jsonRequest, _ := json.Marshal(request)
@@ -198,7 +249,65 @@ func (c *ConfigurationClient) UpdateDeliveryServiceInstance(uuid string, body *D
 	deliveryServiceInstance := &DeliveryServiceInstance{}
 	json.Unmarshal(respBody, deliveryServiceInstance)
 
-	return deliveryServiceInstance, response, nil
+	err = c.coerceDeliveryServiceInstance(deliveryServiceInstance)
+
+	return deliveryServiceInstance, response, err
+}
+
+// ReassignDeliveryServiceInstance replaces the owners of the said instance with the said accounts, leaving its body
+// unchanged
+func (c *ConfigurationClient) ReassignDeliveryServiceInstance(uuid string, accounts []Account) (*DeliveryServiceInstance, *http.Response, error) {
+	current, response, err := c.GetDeliveryServiceInstance(uuid)
+	if err != nil {
+		return nil, response, err
+	}
+
+	return c.UpdateDeliveryServiceInstanceWithAccounts(uuid, &current.Body, accounts)
+}
+
+// AddDeliveryServiceInstanceAccounts adds the said shortnames to the owners of the said instance
+func (c *ConfigurationClient) AddDeliveryServiceInstanceAccounts(uuid string, shortnames ...string) (*DeliveryServiceInstance, *http.Response, error) {
+	current, response, err := c.GetDeliveryServiceInstance(uuid)
+	if err != nil {
+		return nil, response, err
+	}
+
+	accounts := current.Accounts
+	for _, shortname := range shortnames {
+		accounts = mergeAccounts(accounts, Account{Shortname: shortname})
+	}
+	return c.UpdateDeliveryServiceInstanceWithAccounts(uuid, &current.Body, accounts)
+}
+
+// RemoveDeliveryServiceInstanceAccounts removes the said shortnames from the owners of the said instance. At least
+// one owner must remain.
+func (c *ConfigurationClient) RemoveDeliveryServiceInstanceAccounts(uuid string, shortnames ...string) (*DeliveryServiceInstance, *http.Response, error) {
+	current, response, err := c.GetDeliveryServiceInstance(uuid)
+	if err != nil {
+		return nil, response, err
+	}
+
+	removed := map[string]bool{}
+	for _, shortname := range shortnames {
+		removed[shortname] = true
+	}
+	var accounts []Account
+	for _, account := range current.Accounts {
+		if !removed[account.Shortname] {
+			accounts = append(accounts, account)
+		}
+	}
+	return c.UpdateDeliveryServiceInstanceWithAccounts(uuid, &current.Body, accounts)
+}
+
+// mergeAccounts returns accounts with account appended, unless an account with the same shortname is already present
+func mergeAccounts(accounts []Account, account Account) []Account {
+	for _, existing := range accounts {
+		if existing.Shortname == account.Shortname {
+			return accounts
+		}
+	}
+	return append(append([]Account{}, accounts...), account)
 }
 
 func (c *ConfigurationClient) DeleteDeliveryServiceInstance(uuid string) (*DeliveryServiceInstance, *http.Response, error) {
@@ -214,3 +323,87 @@ func (c *ConfigurationClient) DeleteDeliveryServiceInstance(uuid string) (*Deliv
 
 	return deliveryServiceInstance, response, nil
 }
+
+// ListDeliveryServiceInstances lists the instances of the said shortname. Parameters that cannot be coerced are
+// returned as ValidationErrors in a ResponseCoercionError, along with every instance.
+func (c *ConfigurationClient) ListDeliveryServiceInstances(shortname string) ([]DeliveryServiceInstance, *http.Response, error) {
+	<-c.rateLimiter
+	body, response, err := c.Auth.HTTPGet(c.BaseUrl + "/svcinst/delivery/shortname/" + shortname)
+
+	if err != nil {
+		return nil, response, err
+	}
+
+	deliveryServiceInstancesResponse := &DeliveryServiceInstancesResponse{}
+	json.Unmarshal(body, deliveryServiceInstancesResponse)
+
+	err = c.coerceDeliveryServiceInstances(deliveryServiceInstancesResponse.Results)
+
+	return deliveryServiceInstancesResponse.Results, response, err
+}
+
+func (c *ConfigurationClient) EnableDeliveryServiceInstance(uuid string) (*DeliveryServiceInstance, *http.Response, error) {
+	return c.setDeliveryServiceInstanceEnabled(uuid, true)
+}
+
+func (c *ConfigurationClient) DisableDeliveryServiceInstance(uuid string) (*DeliveryServiceInstance, *http.Response, error) {
+	return c.setDeliveryServiceInstanceEnabled(uuid, false)
+}
+
+func (c *ConfigurationClient) setDeliveryServiceInstanceEnabled(uuid string, enabled bool) (*DeliveryServiceInstance, *http.Response, error) {
+	<-c.rateLimiter
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+
+	body, response, err := c.Auth.HTTPPut(c.BaseUrl+"/svcinst/delivery/"+uuid+"/"+action, "")
+
+	if err != nil {
+		return nil, response, err
+	}
+
+	deliveryServiceInstance := &DeliveryServiceInstance{}
+	json.Unmarshal(body, deliveryServiceInstance)
+
+	err = c.coerceDeliveryServiceInstance(deliveryServiceInstance)
+
+	return deliveryServiceInstance, response, err
+}
+
+// SetDeliveryServiceInstancesEnabled enables or disables each of the said instances, continuing past failures
+// so that every instance gets a result
+func (c *ConfigurationClient) SetDeliveryServiceInstancesEnabled(uuids []string, enabled bool) []DeliveryServiceInstanceResult {
+	results := make([]DeliveryServiceInstanceResult, 0, len(uuids))
+	for _, uuid := range uuids {
+		instance, response, err := c.setDeliveryServiceInstanceEnabled(uuid, enabled)
+		results = append(results, DeliveryServiceInstanceResult{
+			UUID:     uuid,
+			Instance: instance,
+			Response: response,
+			Err:      err,
+		})
+	}
+	return results
+}
+
+// SetFilteredDeliveryServiceInstancesEnabled enables or disables every instance of the said shortname matched by
+// filter. Instances already in the requested state are left untouched.
+func (c *ConfigurationClient) SetFilteredDeliveryServiceInstancesEnabled(shortname string, filter DeliveryServiceInstanceFilter, enabled bool) ([]DeliveryServiceInstanceResult, error) {
+	instances, _, err := c.ListDeliveryServiceInstances(shortname)
+	if err != nil {
+		return nil, err
+	}
+
+	var uuids []string
+	for i := range instances {
+		if instances[i].IsEnabled == enabled {
+			continue
+		}
+		if filter == nil || filter(&instances[i]) {
+			uuids = append(uuids, instances[i].UUID)
+		}
+	}
+
+	return c.SetDeliveryServiceInstancesEnabled(uuids, enabled), nil
+}
@@ -0,0 +1,203 @@
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	DocumentFormatJSON = "json"
+	DocumentFormatYAML = "yaml"
+)
+
+// DocumentFormatForPath picks the document format from the extension of the said path
+func DocumentFormatForPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return DocumentFormatJSON, nil
+	case ".yaml", ".yml":
+		return DocumentFormatYAML, nil
+	}
+	return "", fmt.Errorf("cannot tell the document format of %s, expected a .json, .yaml or .yml extension", path)
+}
+
+// DeliveryServiceInstanceDocumentOf strips the server-only fields from the instance, leaving the request that would
+// recreate it
+func DeliveryServiceInstanceDocumentOf(instance *DeliveryServiceInstance) *DeliveryServiceInstanceCreateRequest {
+	return &DeliveryServiceInstanceCreateRequest{
+		Body:     instance.Body,
+		Accounts: instance.Accounts,
+	}
+}
+
+// MarshalDeliveryServiceInstanceDocument serializes the request to a canonical document with sorted keys and
+// normalized option parameters, so that equal requests always produce identical documents
+func MarshalDeliveryServiceInstanceDocument(document *DeliveryServiceInstanceCreateRequest, format string) ([]byte, error) {
+	normalized := *document
+	normalized.Body = *CopyDeliveryServiceInstanceBody(&document.Body)
+	normalizeOptionParameters(&normalized.Body)
+
+	jsonDocument, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err := json.Unmarshal(jsonDocument, &tree); err != nil {
+		return nil, err
+	}
+	tree = normalizeParameter(tree)
+
+	switch format {
+	case DocumentFormatJSON:
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(tree); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	case DocumentFormatYAML:
+		return yaml.Marshal(tree)
+	}
+	return nil, fmt.Errorf("unknown document format %q", format)
+}
+
+// UnmarshalDeliveryServiceInstanceDocument loads a document written by MarshalDeliveryServiceInstanceDocument.
+// Unknown fields are rejected so that typos in hand-edited files are caught.
+func UnmarshalDeliveryServiceInstanceDocument(data []byte, format string) (*DeliveryServiceInstanceCreateRequest, error) {
+	jsonDocument := data
+	switch format {
+	case DocumentFormatJSON:
+	case DocumentFormatYAML:
+		var tree interface{}
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+		tree, err := yamlToJSONTree(tree)
+		if err != nil {
+			return nil, err
+		}
+		if jsonDocument, err = json.Marshal(tree); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown document format %q", format)
+	}
+
+	document := &DeliveryServiceInstanceCreateRequest{}
+	decoder := json.NewDecoder(bytes.NewReader(jsonDocument))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(document); err != nil {
+		return nil, err
+	}
+	normalizeOptionParameters(&document.Body)
+	return document, nil
+}
+
+// WriteDeliveryServiceInstanceFile writes the request to the said path in the format given by its extension. The file
+// is readable by its owner only, as option parameters may hold credentials.
+func WriteDeliveryServiceInstanceFile(path string, document *DeliveryServiceInstanceCreateRequest) error {
+	format, err := DocumentFormatForPath(path)
+	if err != nil {
+		return err
+	}
+	data, err := MarshalDeliveryServiceInstanceDocument(document, format)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// ReadDeliveryServiceInstanceFile loads the request at the said path in the format given by its extension
+func ReadDeliveryServiceInstanceFile(path string) (*DeliveryServiceInstanceCreateRequest, error) {
+	format, err := DocumentFormatForPath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	document, err := UnmarshalDeliveryServiceInstanceDocument(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return document, nil
+}
+
+// normalizeOptionParameters replaces the parameters of every option in the body with their normalized values
+func normalizeOptionParameters(body *DeliveryServiceInstanceBody) {
+	for i := range body.ProtocolSets {
+		for j := range body.ProtocolSets[i].Options {
+			parameters := body.ProtocolSets[i].Options[j].Parameters
+			for k := range parameters {
+				parameters[k] = normalizeParameter(parameters[k])
+			}
+		}
+	}
+}
+
+// normalizeParameter converts whole numbers to int and every other number to float64, however they were decoded, so
+// that a parameter compares equal after a JSON or YAML round trip
+func normalizeParameter(parameter interface{}) interface{} {
+	switch value := parameter.(type) {
+	case []interface{}:
+		for i := range value {
+			value[i] = normalizeParameter(value[i])
+		}
+		return value
+	case map[string]interface{}:
+		for key := range value {
+			value[key] = normalizeParameter(value[key])
+		}
+		return value
+	case string, bool, nil:
+		return value
+	}
+	if number, ok := parameterNumber(parameter); ok {
+		if number == math.Trunc(number) && math.Abs(number) < 1<<53 {
+			return int(number)
+		}
+		return number
+	}
+	return parameter
+}
+
+// yamlToJSONTree converts the map[interface{}]interface{} values produced by the YAML decoder to map[string]interface{}
+func yamlToJSONTree(tree interface{}) (interface{}, error) {
+	switch value := tree.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			keyString, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("document keys must be strings, got %v", key)
+			}
+			convertedItem, err := yamlToJSONTree(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[keyString] = convertedItem
+		}
+		return converted, nil
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, item := range value {
+			convertedItem, err := yamlToJSONTree(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = convertedItem
+		}
+		return converted, nil
+	}
+	return tree, nil
+}
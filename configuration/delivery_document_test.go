@@ -0,0 +1,90 @@
+package configuration
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeliveryServiceInstanceDocumentRoundTrip(t *testing.T) {
+	sourcePort := 8443
+	instance := &DeliveryServiceInstance{
+		UUID:      "6d2e1b8a",
+		IsLatest:  true,
+		IsEnabled: true,
+		Revision:  Revision{CreatedBy: "someone", VersionNumber: 7},
+		Accounts:  []Account{{Shortname: "acct"}, {Shortname: "partner"}},
+		Shortname: "acct",
+		Body: DeliveryServiceInstanceBody{
+			ServiceProfileName: "LLNW-Generic",
+			PublishedHostname:  "www.example.com",
+			PublishedURLPath:   "/video",
+			SourceHostname:     "origin.example.com",
+			SourceURLPath:      "/",
+			ProtocolSets: []ProtocolSet{{
+				PublishedProtocol: "HTTPS",
+				SourceProtocol:    "HTTPS",
+				SourcePort:        &sourcePort,
+				Options: []Option{
+					{Name: "refresh_absmin", Parameters: []interface{}{3600.0}},
+					{Name: "compress", Parameters: []interface{}{true, 6.5}},
+					{Name: "req_send_header", Parameters: []interface{}{"X-Forwarded-Host", "www.example.com"}},
+					{Name: "hosts", Parameters: []interface{}{[]interface{}{"a.example.com", "b.example.com"}}},
+				},
+			}},
+		},
+	}
+
+	for _, format := range []string{DocumentFormatJSON, DocumentFormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			document := DeliveryServiceInstanceDocumentOf(instance)
+			data, err := MarshalDeliveryServiceInstanceDocument(document, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, serverOnly := range []string{"uuid", "isLatest", "revision", "6d2e1b8a", "someone"} {
+				if bytes.Contains(data, []byte(serverOnly)) {
+					t.Errorf("document holds the server-only %q:\n%s", serverOnly, data)
+				}
+			}
+
+			loaded, err := UnmarshalDeliveryServiceInstanceDocument(data, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(loaded.Accounts, instance.Accounts) {
+				t.Errorf("accounts = %+v, want %+v", loaded.Accounts, instance.Accounts)
+			}
+			var parameters [][]interface{}
+			for _, option := range loaded.Body.ProtocolSets[0].Options {
+				parameters = append(parameters, option.Parameters)
+			}
+			wantParameters := [][]interface{}{{3600}, {true, 6.5}, {"X-Forwarded-Host", "www.example.com"}, {[]interface{}{"a.example.com", "b.example.com"}}}
+			if !reflect.DeepEqual(parameters, wantParameters) {
+				t.Errorf("parameters = %#v, want %#v", parameters, wantParameters)
+			}
+			loadedBody, body := loaded.Body, instance.Body
+			loadedBody.ProtocolSets, body.ProtocolSets = nil, nil
+			if !reflect.DeepEqual(loadedBody, body) || *loaded.Body.ProtocolSets[0].SourcePort != 8443 {
+				t.Errorf("body = %+v, want %+v", loaded.Body, instance.Body)
+			}
+
+			again, err := MarshalDeliveryServiceInstanceDocument(loaded, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(again, data) {
+				t.Errorf("document changed after a round trip:\n%s\nwant:\n%s", again, data)
+			}
+		})
+	}
+}
+
+func TestUnmarshalDeliveryServiceInstanceDocumentRejectsUnknownFields(t *testing.T) {
+	data := "body:\n  publishedHostname: www.example.com\n  sourceHostnmae: origin.example.com\n"
+	_, err := UnmarshalDeliveryServiceInstanceDocument([]byte(data), DocumentFormatYAML)
+	if err == nil || !strings.Contains(err.Error(), "sourceHostnmae") {
+		t.Errorf("UnmarshalDeliveryServiceInstanceDocument() = %v, want an error naming the misspelt field", err)
+	}
+}
@@ -0,0 +1,319 @@
+package configuration
+
+import (
+	"encoding/json"
+	"math"
+)
+
+const (
+	OptionNameRefreshAbsMin       = "refresh_absmin"
+	OptionNameRefreshAbsMax       = "refresh_absmax"
+	OptionNameRequestSendHeader   = "req_send_header"
+	OptionNameReplySendHeader     = "reply_send_header"
+	OptionNameReplyDropHeader     = "reply_drop_header"
+	OptionNameOriginBasicAuth     = "origin_basic_auth"
+	OptionNameRedirect            = "redirect"
+	OptionNameCompress            = "compress"
+	OptionNameMediaVaultSecret    = "mediavault_hash_secret"
+	OptionNameCORSAllowOrigin     = "cors_allow_origin"
+	OptionNameCORSAllowMethods    = "cors_allow_methods"
+	OptionNameCORSAllowHeaders    = "cors_allow_headers"
+	OptionNameCORSAllowCredential = "cors_allow_credentials"
+)
+
+// TypedOption is a delivery option with typed parameters
+type TypedOption interface {
+	// Option converts the typed option to the Option sent to the API
+	Option() Option
+}
+
+// RefreshAbsMinOption sets the minimum time in seconds that content is cached, regardless of origin headers
+type RefreshAbsMinOption struct {
+	Seconds int
+}
+
+func (o RefreshAbsMinOption) Option() Option {
+	return Option{Name: OptionNameRefreshAbsMin, Parameters: []interface{}{o.Seconds}}
+}
+
+// RefreshAbsMaxOption sets the maximum time in seconds that content is cached, regardless of origin headers
+type RefreshAbsMaxOption struct {
+	Seconds int
+}
+
+func (o RefreshAbsMaxOption) Option() Option {
+	return Option{Name: OptionNameRefreshAbsMax, Parameters: []interface{}{o.Seconds}}
+}
+
+// RequestSendHeaderOption sets a header on requests sent to the origin
+type RequestSendHeaderOption struct {
+	Header string
+	Value  string
+}
+
+func (o RequestSendHeaderOption) Option() Option {
+	return Option{Name: OptionNameRequestSendHeader, Parameters: []interface{}{o.Header, o.Value}}
+}
+
+// ReplySendHeaderOption sets a header on responses sent to clients
+type ReplySendHeaderOption struct {
+	Header string
+	Value  string
+}
+
+func (o ReplySendHeaderOption) Option() Option {
+	return Option{Name: OptionNameReplySendHeader, Parameters: []interface{}{o.Header, o.Value}}
+}
+
+// ReplyDropHeaderOption removes a header from responses sent to clients
+type ReplyDropHeaderOption struct {
+	Header string
+}
+
+func (o ReplyDropHeaderOption) Option() Option {
+	return Option{Name: OptionNameReplyDropHeader, Parameters: []interface{}{o.Header}}
+}
+
+// OriginBasicAuthOption authenticates requests to the origin with HTTP basic authentication
+type OriginBasicAuthOption struct {
+	Username string
+	Password string
+}
+
+func (o OriginBasicAuthOption) Option() Option {
+	return Option{Name: OptionNameOriginBasicAuth, Parameters: []interface{}{o.Username, o.Password}}
+}
+
+// RedirectOption answers every request with a redirect to Location
+type RedirectOption struct {
+	StatusCode int
+	Location   string
+}
+
+func (o RedirectOption) Option() Option {
+	return Option{Name: OptionNameRedirect, Parameters: []interface{}{o.StatusCode, o.Location}}
+}
+
+// CompressOption compresses responses with the said content types
+type CompressOption struct {
+	ContentTypes []string
+}
+
+func (o CompressOption) Option() Option {
+	return Option{Name: OptionNameCompress, Parameters: stringParameters(o.ContentTypes)}
+}
+
+// MediaVaultSecretOption protects content with MediaVault URL signing using Secret
+type MediaVaultSecretOption struct {
+	Secret string
+}
+
+func (o MediaVaultSecretOption) Option() Option {
+	return Option{Name: OptionNameMediaVaultSecret, Parameters: []interface{}{o.Secret}}
+}
+
+// CORSAllowOriginOption sets the origins allowed to make cross-origin requests
+type CORSAllowOriginOption struct {
+	Origins []string
+}
+
+func (o CORSAllowOriginOption) Option() Option {
+	return Option{Name: OptionNameCORSAllowOrigin, Parameters: stringParameters(o.Origins)}
+}
+
+// CORSAllowMethodsOption sets the methods allowed in cross-origin requests
+type CORSAllowMethodsOption struct {
+	Methods []string
+}
+
+func (o CORSAllowMethodsOption) Option() Option {
+	return Option{Name: OptionNameCORSAllowMethods, Parameters: stringParameters(o.Methods)}
+}
+
+// CORSAllowHeadersOption sets the headers allowed in cross-origin requests
+type CORSAllowHeadersOption struct {
+	Headers []string
+}
+
+func (o CORSAllowHeadersOption) Option() Option {
+	return Option{Name: OptionNameCORSAllowHeaders, Parameters: stringParameters(o.Headers)}
+}
+
+// CORSAllowCredentialsOption sets whether cross-origin requests may include credentials
+type CORSAllowCredentialsOption struct {
+	Allow bool
+}
+
+func (o CORSAllowCredentialsOption) Option() Option {
+	return Option{Name: OptionNameCORSAllowCredential, Parameters: []interface{}{o.Allow}}
+}
+
+// UnknownOption holds an option that has no typed equivalent, or whose parameters do not match it, unchanged
+type UnknownOption struct {
+	Raw Option
+}
+
+func (o UnknownOption) Option() Option {
+	return o.Raw
+}
+
+var typedOptionParsers = map[string]func(parameters []interface{}) (TypedOption, bool){
+	OptionNameRefreshAbsMin: func(parameters []interface{}) (TypedOption, bool) {
+		seconds, ok := intParameters(parameters)
+		return RefreshAbsMinOption{Seconds: seconds}, ok
+	},
+	OptionNameRefreshAbsMax: func(parameters []interface{}) (TypedOption, bool) {
+		seconds, ok := intParameters(parameters)
+		return RefreshAbsMaxOption{Seconds: seconds}, ok
+	},
+	OptionNameRequestSendHeader: func(parameters []interface{}) (TypedOption, bool) {
+		values, ok := fixedStringParameters(parameters, 2)
+		if !ok {
+			return nil, false
+		}
+		return RequestSendHeaderOption{Header: values[0], Value: values[1]}, true
+	},
+	OptionNameReplySendHeader: func(parameters []interface{}) (TypedOption, bool) {
+		values, ok := fixedStringParameters(parameters, 2)
+		if !ok {
+			return nil, false
+		}
+		return ReplySendHeaderOption{Header: values[0], Value: values[1]}, true
+	},
+	OptionNameReplyDropHeader: func(parameters []interface{}) (TypedOption, bool) {
+		values, ok := fixedStringParameters(parameters, 1)
+		if !ok {
+			return nil, false
+		}
+		return ReplyDropHeaderOption{Header: values[0]}, true
+	},
+	OptionNameOriginBasicAuth: func(parameters []interface{}) (TypedOption, bool) {
+		values, ok := fixedStringParameters(parameters, 2)
+		if !ok {
+			return nil, false
+		}
+		return OriginBasicAuthOption{Username: values[0], Password: values[1]}, true
+	},
+	OptionNameRedirect: func(parameters []interface{}) (TypedOption, bool) {
+		if len(parameters) != 2 {
+			return nil, false
+		}
+		statusCode, ok := intParameters(parameters[:1])
+		location, isString := parameters[1].(string)
+		if !ok || !isString {
+			return nil, false
+		}
+		return RedirectOption{StatusCode: statusCode, Location: location}, true
+	},
+	OptionNameCompress: func(parameters []interface{}) (TypedOption, bool) {
+		values, ok := variadicStringParameters(parameters)
+		return CompressOption{ContentTypes: values}, ok
+	},
+	OptionNameMediaVaultSecret: func(parameters []interface{}) (TypedOption, bool) {
+		values, ok := fixedStringParameters(parameters, 1)
+		if !ok {
+			return nil, false
+		}
+		return MediaVaultSecretOption{Secret: values[0]}, true
+	},
+	OptionNameCORSAllowOrigin: func(parameters []interface{}) (TypedOption, bool) {
+		values, ok := variadicStringParameters(parameters)
+		return CORSAllowOriginOption{Origins: values}, ok
+	},
+	OptionNameCORSAllowMethods: func(parameters []interface{}) (TypedOption, bool) {
+		values, ok := variadicStringParameters(parameters)
+		return CORSAllowMethodsOption{Methods: values}, ok
+	},
+	OptionNameCORSAllowHeaders: func(parameters []interface{}) (TypedOption, bool) {
+		values, ok := variadicStringParameters(parameters)
+		return CORSAllowHeadersOption{Headers: values}, ok
+	},
+	OptionNameCORSAllowCredential: func(parameters []interface{}) (TypedOption, bool) {
+		if len(parameters) != 1 {
+			return nil, false
+		}
+		allow, ok := parameters[0].(bool)
+		return CORSAllowCredentialsOption{Allow: allow}, ok
+	},
+}
+
+// ParseOption converts an Option to its typed equivalent. Options without a typed equivalent, or whose parameters
+// would not survive the conversion, are returned as an UnknownOption.
+func ParseOption(option Option) TypedOption {
+	if parse, ok := typedOptionParsers[option.Name]; ok {
+		if typedOption, ok := parse(option.Parameters); ok {
+			return typedOption
+		}
+	}
+	return UnknownOption{Raw: option}
+}
+
+// ParseOptions converts every Option to its typed equivalent, preserving order
+func ParseOptions(options []Option) []TypedOption {
+	typedOptions := make([]TypedOption, len(options))
+	for i, option := range options {
+		typedOptions[i] = ParseOption(option)
+	}
+	return typedOptions
+}
+
+// BuildOptions converts typed options back to the Options sent to the API, preserving order
+func BuildOptions(typedOptions ...TypedOption) []Option {
+	options := make([]Option, len(typedOptions))
+	for i, typedOption := range typedOptions {
+		options[i] = typedOption.Option()
+	}
+	return options
+}
+
+func stringParameters(values []string) []interface{} {
+	if values == nil {
+		return nil
+	}
+	parameters := make([]interface{}, len(values))
+	for i, value := range values {
+		parameters[i] = value
+	}
+	return parameters
+}
+
+func intParameters(parameters []interface{}) (int, bool) {
+	if len(parameters) != 1 {
+		return 0, false
+	}
+	switch value := parameters[0].(type) {
+	case int:
+		return value, true
+	case float64:
+		if value == math.Trunc(value) {
+			return int(value), true
+		}
+	case json.Number:
+		if number, err := value.Int64(); err == nil {
+			return int(number), true
+		}
+	}
+	return 0, false
+}
+
+func fixedStringParameters(parameters []interface{}, count int) ([]string, bool) {
+	if len(parameters) != count {
+		return nil, false
+	}
+	return variadicStringParameters(parameters)
+}
+
+func variadicStringParameters(parameters []interface{}) ([]string, bool) {
+	if parameters == nil {
+		return nil, true
+	}
+	values := make([]string, len(parameters))
+	for i, parameter := range parameters {
+		value, ok := parameter.(string)
+		if !ok {
+			return nil, false
+		}
+		values[i] = value
+	}
+	return values, true
+}
@@ -0,0 +1,40 @@
+package configuration
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateDeliveryServiceInstanceKeepsOwners(t *testing.T) {
+	tests := []struct {
+		name      string
+		shortname string
+		owners    []Account
+		want      []Account
+	}{
+		{name: "single owner", shortname: "acct", owners: []Account{{Shortname: "acct"}}, want: []Account{{Shortname: "acct"}}},
+		{name: "co-owned", shortname: "acct", owners: []Account{{Shortname: "acct"}, {Shortname: "partner"}}, want: []Account{{Shortname: "acct"}, {Shortname: "partner"}}},
+		{name: "shortname not an owner yet", shortname: "acct", owners: []Account{{Shortname: "partner"}}, want: []Account{{Shortname: "partner"}, {Shortname: "acct"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sent DeliveryServiceInstanceUpdateRequest
+			client, stop := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "PUT" {
+					json.NewDecoder(r.Body).Decode(&sent)
+				}
+				json.NewEncoder(w).Encode(DeliveryServiceInstance{UUID: "a", Accounts: test.owners})
+			}))
+			defer stop()
+
+			if _, _, err := client.UpdateDeliveryServiceInstance("a", &DeliveryServiceInstanceBody{SourceHostname: "origin.example.com"}, test.shortname); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sent.Accounts, test.want) || sent.Body.SourceHostname != "origin.example.com" {
+				t.Errorf("sent %+v, want accounts %+v", sent, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package configuration
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+
+	"github.com/llnw/llnw-sdk-go/internal/ipnet"
+)
+
+// IPRangeSet holds the networks of an IP allow list, merged into sorted address intervals for fast lookups
+type IPRangeSet struct {
+	ipv4Networks  []*net.IPNet
+	ipv6Networks  []*net.IPNet
+	ipv4Intervals []ipInterval
+	ipv6Intervals []ipInterval
+}
+
+// IPAllowListDiff lists the address ranges covered by one IP allow list but not the other, as minimal sets of
+// networks
+type IPAllowListDiff struct {
+	Added   []*net.IPNet
+	Removed []*net.IPNet
+}
+
+// IsEmpty reports whether both allow lists cover the same addresses
+func (d *IPAllowListDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// ipInterval is an inclusive range of addresses, both 4 bytes long for IPv4 and 16 bytes long for IPv6
+type ipInterval struct {
+	first net.IP
+	last  net.IP
+}
+
+// NewIPRangeSet builds an IPRangeSet from networks
+func NewIPRangeSet(networks ...*net.IPNet) *IPRangeSet {
+	s := &IPRangeSet{}
+	for _, network := range networks {
+		network = canonicalNetwork(network)
+		if len(network.IP) == net.IPv4len {
+			s.ipv4Networks = append(s.ipv4Networks, network)
+		} else {
+			s.ipv6Networks = append(s.ipv6Networks, network)
+		}
+	}
+	s.ipv4Intervals = mergeIntervals(s.ipv4Networks)
+	s.ipv6Intervals = mergeIntervals(s.ipv6Networks)
+	return s
+}
+
+// ParseIPRanges parses CIDR ranges and single addresses into an IPRangeSet. Every malformed range is reported in the
+// returned ValidationErrors.
+func ParseIPRanges(ranges []string) (*IPRangeSet, error) {
+	var networks []*net.IPNet
+	var validationErrors ValidationErrors
+	for i, ipRange := range ranges {
+		network, err := ipnet.ParseNetwork(ipRange)
+		if err != nil {
+			validationErrors = append(validationErrors, ValidationError{Path: fmt.Sprintf("ipAllowList[%d]", i), Message: err.Error()})
+			continue
+		}
+		networks = append(networks, network)
+	}
+	if len(validationErrors) > 0 {
+		return nil, validationErrors
+	}
+	return NewIPRangeSet(networks...), nil
+}
+
+// RangeSet parses the ranges of the allow list
+func (l *IPAllowList) RangeSet() (*IPRangeSet, error) {
+	return ParseIPRanges(l.IPRanges)
+}
+
+// IPv4Networks returns the IPv4 networks of the set, as given
+func (s *IPRangeSet) IPv4Networks() []*net.IPNet {
+	return s.ipv4Networks
+}
+
+// IPv6Networks returns the IPv6 networks of the set, as given
+func (s *IPRangeSet) IPv6Networks() []*net.IPNet {
+	return s.ipv6Networks
+}
+
+// Contains reports whether ip is within any network of the set
+func (s *IPRangeSet) Contains(ip net.IP) bool {
+	intervals := s.ipv6Intervals
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, intervals = ip4, s.ipv4Intervals
+	} else if ip = ip.To16(); ip == nil {
+		return false
+	}
+	i := sort.Search(len(intervals), func(i int) bool {
+		return bytes.Compare(intervals[i].last, ip) >= 0
+	})
+	return i < len(intervals) && bytes.Compare(intervals[i].first, ip) <= 0
+}
+
+// Aggregate returns the smallest list of networks covering the same addresses as the set, merging adjacent and
+// overlapping networks. IPv4 networks come first, each family sorted by address.
+func (s *IPRangeSet) Aggregate() []*net.IPNet {
+	return append(intervalNetworks(s.ipv4Intervals), intervalNetworks(s.ipv6Intervals)...)
+}
+
+// AggregateNetworks returns the smallest list of networks covering the same addresses as networks
+func AggregateNetworks(networks []*net.IPNet) []*net.IPNet {
+	return NewIPRangeSet(networks...).Aggregate()
+}
+
+// DiffIPAllowLists returns the addresses allowed by new but not by old as Added, and those allowed by old but not by
+// new as Removed
+func DiffIPAllowLists(old *IPAllowList, new *IPAllowList) (*IPAllowListDiff, error) {
+	oldSet, err := old.RangeSet()
+	if err != nil {
+		return nil, fmt.Errorf("parsing IP allow list version %d: %s", old.Version, err)
+	}
+	newSet, err := new.RangeSet()
+	if err != nil {
+		return nil, fmt.Errorf("parsing IP allow list version %d: %s", new.Version, err)
+	}
+	return &IPAllowListDiff{
+		Added: append(intervalNetworks(subtractIntervals(newSet.ipv4Intervals, oldSet.ipv4Intervals)),
+			intervalNetworks(subtractIntervals(newSet.ipv6Intervals, oldSet.ipv6Intervals))...),
+		Removed: append(intervalNetworks(subtractIntervals(oldSet.ipv4Intervals, newSet.ipv4Intervals)),
+			intervalNetworks(subtractIntervals(oldSet.ipv6Intervals, newSet.ipv6Intervals))...),
+	}, nil
+}
+
+// canonicalNetwork returns the network with its address masked, and 4 bytes long for IPv4 networks
+func canonicalNetwork(network *net.IPNet) *net.IPNet {
+	ip, mask := network.IP, network.Mask
+	if ip4 := ip.To4(); ip4 != nil && len(mask) == net.IPv4len {
+		ip = ip4
+	} else {
+		ip = ip.To16()
+		if len(mask) == net.IPv4len {
+			ones, _ := mask.Size()
+			mask = net.CIDRMask(ones+8*(net.IPv6len-net.IPv4len), 8*net.IPv6len)
+		}
+	}
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
+
+// mergeIntervals sorts the address intervals of networks of a single family and merges adjacent and overlapping ones
+func mergeIntervals(networks []*net.IPNet) []ipInterval {
+	var intervals []ipInterval
+	for _, network := range networks {
+		last := make(net.IP, len(network.IP))
+		for i := range last {
+			last[i] = network.IP[i] | ^network.Mask[i]
+		}
+		intervals = append(intervals, ipInterval{first: network.IP, last: last})
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return bytes.Compare(intervals[i].first, intervals[j].first) < 0
+	})
+
+	var merged []ipInterval
+	for _, interval := range intervals {
+		if n := len(merged); n > 0 && !addressAfter(interval.first, merged[n-1].last) {
+			if bytes.Compare(interval.last, merged[n-1].last) > 0 {
+				merged[n-1].last = interval.last
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// subtractIntervals returns the parts of the sorted, merged intervals a that are not within the sorted, merged
+// intervals b
+func subtractIntervals(a []ipInterval, b []ipInterval) []ipInterval {
+	var result []ipInterval
+	j := 0
+	for _, interval := range a {
+		first := addressInt(interval.first)
+		last := addressInt(interval.last)
+		for j < len(b) && bytes.Compare(b[j].last, interval.first) < 0 {
+			j++
+		}
+		for k := j; k < len(b) && bytes.Compare(b[k].first, interval.last) <= 0 && first.Cmp(last) <= 0; k++ {
+			removedFirst, removedLast := addressInt(b[k].first), addressInt(b[k].last)
+			if removedFirst.Cmp(first) > 0 {
+				result = append(result, ipInterval{
+					first: intAddress(first, len(interval.first)),
+					last:  intAddress(new(big.Int).Sub(removedFirst, big.NewInt(1)), len(interval.first)),
+				})
+			}
+			first = new(big.Int).Add(removedLast, big.NewInt(1))
+		}
+		if first.Cmp(last) <= 0 {
+			result = append(result, ipInterval{first: intAddress(first, len(interval.first)), last: interval.last})
+		}
+	}
+	return result
+}
+
+// intervalNetworks splits intervals into the fewest networks covering them
+func intervalNetworks(intervals []ipInterval) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, interval := range intervals {
+		bits := 8 * len(interval.first)
+		first, last := addressInt(interval.first), addressInt(interval.last)
+		for first.Cmp(last) <= 0 {
+			size := bits
+			if first.Sign() != 0 {
+				size = int(first.TrailingZeroBits())
+			}
+			for size > 0 && new(big.Int).Add(first, new(big.Int).Lsh(big.NewInt(1), uint(size))).Cmp(new(big.Int).Add(last, big.NewInt(1))) > 0 {
+				size--
+			}
+			networks = append(networks, &net.IPNet{IP: intAddress(first, len(interval.first)), Mask: net.CIDRMask(bits-size, bits)})
+			first = new(big.Int).Add(first, new(big.Int).Lsh(big.NewInt(1), uint(size)))
+		}
+	}
+	return networks
+}
+
+// addressAfter reports whether ip comes after the address following previous, leaving a gap between them
+func addressAfter(ip net.IP, previous net.IP) bool {
+	next := new(big.Int).Add(addressInt(previous), big.NewInt(1))
+	return addressInt(ip).Cmp(next) > 0
+}
+
+func addressInt(ip net.IP) *big.Int {
+	return new(big.Int).SetBytes(ip)
+}
+
+func intAddress(n *big.Int, length int) net.IP {
+	ip := make(net.IP, length)
+	b := n.Bytes()
+	copy(ip[length-len(b):], b)
+	return ip
+}
@@ -0,0 +1,126 @@
+package configuration
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestIPRangeSetAggregate(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []string
+		want   []string
+	}{
+		{name: "empty"},
+		{name: "single address", ranges: []string{"192.0.2.7"}, want: []string{"192.0.2.7/32"}},
+		{name: "adjacent networks", ranges: []string{"10.0.0.0/24", "10.0.1.0/24"}, want: []string{"10.0.0.0/23"}},
+		{name: "contained network", ranges: []string{"10.0.0.0/24", "10.0.0.128/25"}, want: []string{"10.0.0.0/24"}},
+		{name: "unaligned adjacent networks", ranges: []string{"10.0.1.0/24", "10.0.2.0/24"}, want: []string{"10.0.1.0/24", "10.0.2.0/24"}},
+		{name: "unmasked address", ranges: []string{"10.0.0.5/24"}, want: []string{"10.0.0.0/24"}},
+		{
+			name:   "mixed families",
+			ranges: []string{"10.0.0.0/24", "2001:db8::/33", "10.0.1.0/24", "10.0.0.128/25", "192.0.2.7", "2001:db8:8000::/33", "10.0.3.0/24"},
+			want:   []string{"10.0.0.0/23", "10.0.3.0/24", "192.0.2.7/32", "2001:db8::/32"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set, err := ParseIPRanges(test.ranges)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := networkStrings(set.Aggregate()); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Aggregate() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIPRangeSetContains(t *testing.T) {
+	set, err := ParseIPRanges([]string{"10.0.0.0/24", "10.0.2.0/24", "192.0.2.7", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "10.0.0.0", want: true},
+		{ip: "10.0.0.255", want: true},
+		{ip: "10.0.1.1", want: false},
+		{ip: "10.0.2.9", want: true},
+		{ip: "192.0.2.7", want: true},
+		{ip: "192.0.2.8", want: false},
+		{ip: "::ffff:10.0.0.1", want: true},
+		{ip: "2001:db8:ffff::1", want: true},
+		{ip: "2001:db9::1", want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			if got := set.Contains(net.ParseIP(test.ip)); got != test.want {
+				t.Errorf("Contains(%s) = %v, want %v", test.ip, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseIPRangesErrors(t *testing.T) {
+	_, err := ParseIPRanges([]string{"10.0.0.0/24", "10.0.0.0/33", "192.0.2.7", "not-an-ip"})
+	validationErrors, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("ParseIPRanges() returned %T, want ValidationErrors", err)
+	}
+	var got []string
+	for _, validationError := range validationErrors {
+		got = append(got, validationError.Path)
+	}
+	if want := []string{"ipAllowList[1]", "ipAllowList[3]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseIPRanges() error paths = %v, want %v", got, want)
+	}
+}
+
+func TestDiffIPAllowLists(t *testing.T) {
+	tests := []struct {
+		name        string
+		old         []string
+		new         []string
+		wantAdded   []string
+		wantRemoved []string
+	}{
+		{name: "identical", old: []string{"10.0.0.0/24"}, new: []string{"10.0.0.0/24"}},
+		{name: "same addresses written differently", old: []string{"10.0.0.0/24", "10.0.1.0/24"}, new: []string{"10.0.0.0/23"}},
+		{name: "added network", old: []string{"10.0.0.0/24"}, new: []string{"10.0.0.0/24", "192.0.2.7"}, wantAdded: []string{"192.0.2.7/32"}},
+		{
+			name:        "widened and removed",
+			old:         []string{"10.0.0.0/24", "::/0"},
+			new:         []string{"10.0.0.0/23", "10.0.0.5"},
+			wantAdded:   []string{"10.0.1.0/24"},
+			wantRemoved: []string{"::/0"},
+		},
+		{name: "hole punched", old: []string{"10.0.0.0/8"}, new: []string{"10.0.0.0/9", "10.192.0.0/10"}, wantRemoved: []string{"10.128.0.0/10"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff, err := DiffIPAllowLists(&IPAllowList{IPRanges: test.old, Version: 1}, &IPAllowList{IPRanges: test.new, Version: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := networkStrings(diff.Added); !reflect.DeepEqual(got, test.wantAdded) {
+				t.Errorf("DiffIPAllowLists() added = %v, want %v", got, test.wantAdded)
+			}
+			if got := networkStrings(diff.Removed); !reflect.DeepEqual(got, test.wantRemoved) {
+				t.Errorf("DiffIPAllowLists() removed = %v, want %v", got, test.wantRemoved)
+			}
+			if diff.IsEmpty() != (test.wantAdded == nil && test.wantRemoved == nil) {
+				t.Errorf("IsEmpty() = %v", diff.IsEmpty())
+			}
+		})
+	}
+}
+
+func TestDiffIPAllowListsInvalid(t *testing.T) {
+	if _, err := DiffIPAllowLists(&IPAllowList{IPRanges: []string{"bad"}, Version: 1}, &IPAllowList{Version: 2}); err == nil {
+		t.Error("DiffIPAllowLists() with an invalid range succeeded")
+	}
+}
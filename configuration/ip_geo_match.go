@@ -0,0 +1,231 @@
+package configuration
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/llnw/llnw-sdk-go/internal/ipnet"
+)
+
+// Rule names of the IPGeoMatch expression of a realtime streaming slot
+const (
+	IPGeoMatchAllowCountries = "allowCountries"
+	IPGeoMatchDenyCountries  = "denyCountries"
+	IPGeoMatchAllowNetworks  = "allowNetworks"
+	IPGeoMatchDenyNetworks   = "denyNetworks"
+)
+
+// isoCountryCodes lists every ISO 3166-1 alpha-2 country code
+var isoCountryCodes = stringSet(strings.Fields(`
+	AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ
+	CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO
+	FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE
+	JM JO JP KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO
+	MP MQ MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW
+	PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM
+	TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW`))
+
+// IPGeoMatch restricts the viewers of a realtime streaming slot by network and country. Its expression form, stored
+// in RealtimeStreamingSlot.IPGeoMatch, is a semicolon separated list of rules, each a rule name and a comma
+// separated list of values:
+//
+//	allowCountries=US,CA;denyNetworks=192.0.2.0/24,2001:db8::/32
+//
+// The zero value allows every viewer.
+type IPGeoMatch struct {
+	AllowedCountries []string
+	DeniedCountries  []string
+	AllowedNetworks  []*net.IPNet
+	DeniedNetworks   []*net.IPNet
+}
+
+// NewIPGeoMatch returns an IPGeoMatch allowing every viewer, to be restricted with its builder methods
+func NewIPGeoMatch() *IPGeoMatch {
+	return &IPGeoMatch{}
+}
+
+// AllowCountries allows viewers from the said countries only, unless their network is allowed
+func (m *IPGeoMatch) AllowCountries(codes ...string) *IPGeoMatch {
+	m.AllowedCountries = append(m.AllowedCountries, upperCountryCodes(codes)...)
+	return m
+}
+
+// DenyCountries denies viewers from the said countries, unless their network is allowed
+func (m *IPGeoMatch) DenyCountries(codes ...string) *IPGeoMatch {
+	m.DeniedCountries = append(m.DeniedCountries, upperCountryCodes(codes)...)
+	return m
+}
+
+// AllowNetworks allows viewers within the said networks whatever their country
+func (m *IPGeoMatch) AllowNetworks(networks ...*net.IPNet) *IPGeoMatch {
+	m.AllowedNetworks = append(m.AllowedNetworks, networks...)
+	return m
+}
+
+// DenyNetworks denies viewers within the said networks
+func (m *IPGeoMatch) DenyNetworks(networks ...*net.IPNet) *IPGeoMatch {
+	m.DeniedNetworks = append(m.DeniedNetworks, networks...)
+	return m
+}
+
+// IsCountryCode reports whether code is an ISO 3166-1 alpha-2 country code, in any case
+func IsCountryCode(code string) bool {
+	return isoCountryCodes[strings.ToUpper(code)]
+}
+
+// ParseIPGeoMatch parses an IPGeoMatch expression. An empty expression allows every viewer.
+func ParseIPGeoMatch(expression string) (*IPGeoMatch, error) {
+	match := NewIPGeoMatch()
+	var validationErrors ValidationErrors
+	for _, rule := range strings.Split(expression, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		i := strings.IndexByte(rule, '=')
+		if i < 0 {
+			validationErrors = append(validationErrors, ValidationError{Path: rule, Message: "expected name=values"})
+			continue
+		}
+		name := strings.TrimSpace(rule[:i])
+		var values []string
+		for _, value := range strings.Split(rule[i+1:], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+
+		switch name {
+		case IPGeoMatchAllowCountries:
+			match.AllowCountries(values...)
+		case IPGeoMatchDenyCountries:
+			match.DenyCountries(values...)
+		case IPGeoMatchAllowNetworks, IPGeoMatchDenyNetworks:
+			for _, value := range values {
+				network, err := ipnet.ParseNetwork(value)
+				if err != nil {
+					validationErrors = append(validationErrors, ValidationError{Path: name, Message: err.Error()})
+				} else if name == IPGeoMatchAllowNetworks {
+					match.AllowNetworks(network)
+				} else {
+					match.DenyNetworks(network)
+				}
+			}
+		default:
+			validationErrors = append(validationErrors, ValidationError{Path: name, Message: "unknown rule"})
+		}
+	}
+
+	if err := match.Validate(); err != nil {
+		validationErrors = append(validationErrors, err.(ValidationErrors)...)
+	}
+	if len(validationErrors) > 0 {
+		return nil, validationErrors
+	}
+	return match, nil
+}
+
+// Validate checks that every country is an ISO 3166-1 alpha-2 code and that no country is both allowed and denied
+func (m *IPGeoMatch) Validate() error {
+	var validationErrors ValidationErrors
+	for _, countries := range []struct {
+		rule  string
+		codes []string
+	}{
+		{rule: IPGeoMatchAllowCountries, codes: m.AllowedCountries},
+		{rule: IPGeoMatchDenyCountries, codes: m.DeniedCountries},
+	} {
+		for _, code := range countries.codes {
+			if !IsCountryCode(code) {
+				validationErrors = append(validationErrors, ValidationError{Path: countries.rule, Message: fmt.Sprintf("%q is not an ISO 3166-1 alpha-2 country code", code)})
+			}
+		}
+	}
+	denied := stringSet(upperCountryCodes(m.DeniedCountries))
+	for _, code := range upperCountryCodes(m.AllowedCountries) {
+		if denied[code] {
+			validationErrors = append(validationErrors, ValidationError{Path: IPGeoMatchAllowCountries, Message: fmt.Sprintf("%s is also denied", code)})
+		}
+	}
+	if len(validationErrors) == 0 {
+		return nil
+	}
+	return validationErrors
+}
+
+// String returns the expression form of the IPGeoMatch, with its rules in a fixed order
+func (m *IPGeoMatch) String() string {
+	var rules []string
+	addRule := func(name string, values []string) {
+		if len(values) > 0 {
+			rules = append(rules, name+"="+strings.Join(values, ","))
+		}
+	}
+	addRule(IPGeoMatchAllowCountries, upperCountryCodes(m.AllowedCountries))
+	addRule(IPGeoMatchDenyCountries, upperCountryCodes(m.DeniedCountries))
+	addRule(IPGeoMatchAllowNetworks, networkStrings(m.AllowedNetworks))
+	addRule(IPGeoMatchDenyNetworks, networkStrings(m.DeniedNetworks))
+	return strings.Join(rules, ";")
+}
+
+// Allows reports whether a viewer with the said IP address and ISO country code may watch. Denied networks are
+// checked first, then allowed networks, then denied countries. When countries or networks are allowed, any other
+// viewer is denied.
+func (m *IPGeoMatch) Allows(ip net.IP, country string) bool {
+	if networksContain(m.DeniedNetworks, ip) {
+		return false
+	}
+	if networksContain(m.AllowedNetworks, ip) {
+		return true
+	}
+	country = strings.ToUpper(country)
+	if stringSet(upperCountryCodes(m.DeniedCountries))[country] {
+		return false
+	}
+	if len(m.AllowedCountries) > 0 {
+		return stringSet(upperCountryCodes(m.AllowedCountries))[country]
+	}
+	return len(m.AllowedNetworks) == 0
+}
+
+// ParsedIPGeoMatch parses the IPGeoMatch expression of the slot
+func (s *RealtimeStreamingSlot) ParsedIPGeoMatch() (*IPGeoMatch, error) {
+	return ParseIPGeoMatch(s.IPGeoMatch)
+}
+
+func networksContain(networks []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func networkStrings(networks []*net.IPNet) []string {
+	var values []string
+	for _, network := range networks {
+		values = append(values, network.String())
+	}
+	return values
+}
+
+func upperCountryCodes(codes []string) []string {
+	var upper []string
+	for _, code := range codes {
+		upper = append(upper, strings.ToUpper(strings.TrimSpace(code)))
+	}
+	return upper
+}
+
+func stringSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
@@ -10,7 +10,7 @@ type IPAllowList struct {
 	Version  int      `json:"version"`
 }
 
-func (c ConfigurationClient) GetIPAllowList() (*IPAllowList, *http.Response, error) {
+func (c *ConfigurationClient) GetIPAllowList() (*IPAllowList, *http.Response, error) {
 	object := &IPAllowList{}
 	body, response, err := c.Auth.HTTPGet("https://control.llnw.com/aportal/api/ipam/getIpAllowList.do")
 
@@ -0,0 +1,302 @@
+package configuration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+var severityRanks = map[string]int{
+	SeverityInfo:    0,
+	SeverityWarning: 1,
+	SeverityError:   2,
+}
+
+// LintFinding is a single policy violation found in a DeliveryServiceInstanceBody. Rules only fill in Path and
+// Message; the Linter fills in Rule and Severity.
+type LintFinding struct {
+	Rule     string
+	Severity string
+	Path     string
+	Message  string
+}
+
+func (f LintFinding) String() string {
+	return fmt.Sprintf("%s: %s: %s [%s]", f.Severity, f.Path, f.Message, f.Rule)
+}
+
+// LintFindings is the list of findings of a Linter
+type LintFindings []LintFinding
+
+// HasSeverity reports whether any finding is at least as severe as severity
+func (f LintFindings) HasSeverity(severity string) bool {
+	for _, finding := range f {
+		if severityRanks[finding.Severity] >= severityRanks[severity] {
+			return true
+		}
+	}
+	return false
+}
+
+func (f LintFindings) Error() string {
+	messages := make([]string, len(f))
+	for i, finding := range f {
+		messages[i] = finding.String()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// LintRule checks a body against a single policy
+type LintRule interface {
+	Name() string
+	Check(body *DeliveryServiceInstanceBody) []LintFinding
+}
+
+type lintRuleFunc struct {
+	name  string
+	check func(body *DeliveryServiceInstanceBody) []LintFinding
+}
+
+func (r lintRuleFunc) Name() string {
+	return r.name
+}
+
+func (r lintRuleFunc) Check(body *DeliveryServiceInstanceBody) []LintFinding {
+	return r.check(body)
+}
+
+// NewLintRule makes a LintRule from a function, for custom rules
+func NewLintRule(name string, check func(body *DeliveryServiceInstanceBody) []LintFinding) LintRule {
+	return lintRuleFunc{name: name, check: check}
+}
+
+type configuredLintRule struct {
+	rule     LintRule
+	severity string
+}
+
+// Linter checks bodies against a set of rules, each with its own severity, honouring per-instance suppressions
+type Linter struct {
+	rules        []configuredLintRule
+	suppressions map[DeliveryServiceInstanceKey]map[string]bool
+}
+
+// NewLinter returns a Linter with no rules
+func NewLinter() *Linter {
+	return &Linter{suppressions: map[DeliveryServiceInstanceKey]map[string]bool{}}
+}
+
+// AddRule adds a rule whose findings are reported with the said severity
+func (l *Linter) AddRule(rule LintRule, severity string) *Linter {
+	l.rules = append(l.rules, configuredLintRule{rule: rule, severity: severity})
+	return l
+}
+
+// Suppress silences the named rules for the instance with the said key
+func (l *Linter) Suppress(key DeliveryServiceInstanceKey, ruleNames ...string) *Linter {
+	if l.suppressions[key] == nil {
+		l.suppressions[key] = map[string]bool{}
+	}
+	for _, ruleName := range ruleNames {
+		l.suppressions[key][ruleName] = true
+	}
+	return l
+}
+
+// Lint runs every rule that is not suppressed for the body and returns the findings sorted by path
+func (l *Linter) Lint(body *DeliveryServiceInstanceBody) LintFindings {
+	suppressed := l.suppressions[DeliveryServiceInstanceKeyOf(body)]
+
+	var findings LintFindings
+	for _, configured := range l.rules {
+		name := configured.rule.Name()
+		if suppressed[name] {
+			continue
+		}
+		for _, finding := range configured.rule.Check(body) {
+			finding.Rule = name
+			finding.Severity = configured.severity
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Path < findings[j].Path
+	})
+	return findings
+}
+
+// RequirePublishedProtocolRule requires the body to publish the said protocol, for example "HTTPS"
+func RequirePublishedProtocolRule(protocol string) LintRule {
+	protocol = strings.ToUpper(protocol)
+	return NewLintRule("require-published-"+strings.ToLower(protocol), func(body *DeliveryServiceInstanceBody) []LintFinding {
+		for _, protocolSet := range body.ProtocolSets {
+			if strings.ToUpper(protocolSet.PublishedProtocol) == protocol {
+				return nil
+			}
+		}
+		return []LintFinding{{Path: "protocolSets", Message: protocol + " must be published"}}
+	})
+}
+
+// RequireSourceProtocolRule requires every protocol set to fetch from the source with the said protocol
+func RequireSourceProtocolRule(protocol string) LintRule {
+	protocol = strings.ToUpper(protocol)
+	return NewLintRule("require-source-"+strings.ToLower(protocol), func(body *DeliveryServiceInstanceBody) []LintFinding {
+		var findings []LintFinding
+		for i, protocolSet := range body.ProtocolSets {
+			if strings.ToUpper(protocolSet.SourceProtocol) != protocol {
+				findings = append(findings, LintFinding{
+					Path:    fmt.Sprintf("protocolSets[%d].sourceProtocol", i),
+					Message: fmt.Sprintf("source must use %s, got %q", protocol, protocolSet.SourceProtocol),
+				})
+			}
+		}
+		return findings
+	})
+}
+
+// RequireOptionRule requires every protocol set to have at least one of the named options, for example
+// OptionNameRefreshAbsMin or OptionNameRefreshAbsMax to require a cache TTL
+func RequireOptionRule(name string, optionNames ...string) LintRule {
+	return NewLintRule(name, func(body *DeliveryServiceInstanceBody) []LintFinding {
+		var findings []LintFinding
+		for i, protocolSet := range body.ProtocolSets {
+			if !hasAnyOption(protocolSet.Options, optionNames) {
+				findings = append(findings, LintFinding{
+					Path:    fmt.Sprintf("protocolSets[%d].options", i),
+					Message: "one of " + strings.Join(optionNames, ", ") + " is required",
+				})
+			}
+		}
+		return findings
+	})
+}
+
+// ForbidOptionRule forbids the named options in every protocol set
+func ForbidOptionRule(name string, optionNames ...string) LintRule {
+	forbidden := map[string]bool{}
+	for _, optionName := range optionNames {
+		forbidden[optionName] = true
+	}
+	return NewLintRule(name, func(body *DeliveryServiceInstanceBody) []LintFinding {
+		var findings []LintFinding
+		for i, protocolSet := range body.ProtocolSets {
+			for j, option := range protocolSet.Options {
+				if forbidden[option.Name] {
+					findings = append(findings, LintFinding{
+						Path:    fmt.Sprintf("protocolSets[%d].options[%d]", i, j),
+						Message: fmt.Sprintf("option %q is not allowed", option.Name),
+					})
+				}
+			}
+		}
+		return findings
+	})
+}
+
+// NoWildcardHostnamesRule forbids wildcards in the published and source hostnames
+func NoWildcardHostnamesRule() LintRule {
+	return NewLintRule("no-wildcard-hostnames", func(body *DeliveryServiceInstanceBody) []LintFinding {
+		var findings []LintFinding
+		if strings.Contains(body.PublishedHostname, "*") {
+			findings = append(findings, LintFinding{Path: "publishedHostname", Message: "wildcard hostnames are not allowed"})
+		}
+		if strings.Contains(body.SourceHostname, "*") {
+			findings = append(findings, LintFinding{Path: "sourceHostname", Message: "wildcard hostnames are not allowed"})
+		}
+		return findings
+	})
+}
+
+// ValidHostnamesRule requires the published and source hostnames to be syntactically valid DNS names
+func ValidHostnamesRule() LintRule {
+	return NewLintRule("valid-hostnames", func(body *DeliveryServiceInstanceBody) []LintFinding {
+		var findings []LintFinding
+		if message := checkHostname(body.PublishedHostname); message != "" {
+			findings = append(findings, LintFinding{Path: "publishedHostname", Message: message})
+		}
+		if message := checkHostname(body.SourceHostname); message != "" {
+			findings = append(findings, LintFinding{Path: "sourceHostname", Message: message})
+		}
+		return findings
+	})
+}
+
+// URLPathsRule requires the published and source URL paths to be absolute and free of empty or relative segments
+func URLPathsRule() LintRule {
+	return NewLintRule("url-paths", func(body *DeliveryServiceInstanceBody) []LintFinding {
+		var findings []LintFinding
+		if message := checkURLPath(body.PublishedURLPath); message != "" {
+			findings = append(findings, LintFinding{Path: "publishedUrlPath", Message: message})
+		}
+		if message := checkURLPath(body.SourceURLPath); message != "" {
+			findings = append(findings, LintFinding{Path: "sourceUrlPath", Message: message})
+		}
+		return findings
+	})
+}
+
+func hasAnyOption(options []Option, optionNames []string) bool {
+	for _, option := range options {
+		for _, optionName := range optionNames {
+			if option.Name == optionName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkHostname(hostname string) string {
+	if hostname == "" {
+		return "hostname is empty"
+	}
+	if len(hostname) > 253 {
+		return "hostname is longer than 253 characters"
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(hostname, "."), ".") {
+		if label == "*" {
+			continue
+		}
+		if label == "" || len(label) > 63 {
+			return fmt.Sprintf("%q has an empty or overlong label", hostname)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Sprintf("%q has a label starting or ending with a hyphen", hostname)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return fmt.Sprintf("%q contains the invalid character %q", hostname, r)
+			}
+		}
+	}
+	return ""
+}
+
+func checkURLPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Sprintf("%q must start with /", path)
+	}
+	if strings.Contains(path, "//") {
+		return fmt.Sprintf("%q contains an empty segment", path)
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "." || segment == ".." {
+			return fmt.Sprintf("%q contains a relative segment", path)
+		}
+	}
+	if strings.ContainsAny(path, " \t\r\n?#") {
+		return fmt.Sprintf("%q contains whitespace, a query or a fragment", path)
+	}
+	return ""
+}
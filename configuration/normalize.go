@@ -0,0 +1,158 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var defaultSourcePorts = map[string]int{
+	"HTTP":  80,
+	"HTTPS": 443,
+}
+
+// BodyDifference is a single field that differs between two normalized bodies. Old or New is nil when the field
+// only exists on one side.
+type BodyDifference struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+func (d BodyDifference) String() string {
+	return fmt.Sprintf("%s: %s -> %s", d.Path, formatDifferenceValue(d.Old), formatDifferenceValue(d.New))
+}
+
+// Normalize returns a copy of the body in canonical form, so that two bodies the API treats the same compare equal.
+// Hostnames are lowercased, protocols uppercased, empty URL paths become "/", a missing SourcePort is replaced by the
+// default port of the source protocol, option parameters are given consistent numeric types, and protocol sets and
+// options are sorted.
+func Normalize(body *DeliveryServiceInstanceBody) *DeliveryServiceInstanceBody {
+	normalized := CopyDeliveryServiceInstanceBody(body)
+	normalized.PublishedHostname = strings.ToLower(normalized.PublishedHostname)
+	normalized.SourceHostname = strings.ToLower(normalized.SourceHostname)
+	if normalized.PublishedURLPath == "" {
+		normalized.PublishedURLPath = "/"
+	}
+	if normalized.SourceURLPath == "" {
+		normalized.SourceURLPath = "/"
+	}
+	if len(normalized.ProtocolSets) == 0 {
+		normalized.ProtocolSets = nil
+	}
+
+	for i := range normalized.ProtocolSets {
+		protocolSet := &normalized.ProtocolSets[i]
+		protocolSet.PublishedProtocol = strings.ToUpper(protocolSet.PublishedProtocol)
+		protocolSet.SourceProtocol = strings.ToUpper(protocolSet.SourceProtocol)
+		if protocolSet.SourcePort == nil {
+			if port, ok := defaultSourcePorts[protocolSet.SourceProtocol]; ok {
+				protocolSet.SourcePort = &port
+			}
+		}
+
+		if len(protocolSet.Options) == 0 {
+			protocolSet.Options = nil
+			continue
+		}
+		for j := range protocolSet.Options {
+			option := &protocolSet.Options[j]
+			if len(option.Parameters) == 0 {
+				option.Parameters = nil
+			}
+			for k := range option.Parameters {
+				option.Parameters[k] = normalizeParameter(option.Parameters[k])
+			}
+		}
+		sort.SliceStable(protocolSet.Options, func(a, b int) bool {
+			optionA, optionB := protocolSet.Options[a], protocolSet.Options[b]
+			if optionA.Name != optionB.Name {
+				return optionA.Name < optionB.Name
+			}
+			parametersA, _ := json.Marshal(optionA.Parameters)
+			parametersB, _ := json.Marshal(optionB.Parameters)
+			return string(parametersA) < string(parametersB)
+		})
+	}
+
+	sort.SliceStable(normalized.ProtocolSets, func(a, b int) bool {
+		protocolSetA, protocolSetB := normalized.ProtocolSets[a], normalized.ProtocolSets[b]
+		if protocolSetA.PublishedProtocol != protocolSetB.PublishedProtocol {
+			return protocolSetA.PublishedProtocol < protocolSetB.PublishedProtocol
+		}
+		return protocolSetA.SourceProtocol < protocolSetB.SourceProtocol
+	})
+	return normalized
+}
+
+// DeliveryServiceInstanceBodiesEqual reports whether two bodies are equal once normalized
+func DeliveryServiceInstanceBodiesEqual(a *DeliveryServiceInstanceBody, b *DeliveryServiceInstanceBody) bool {
+	return len(DiffDeliveryServiceInstanceBodies(a, b)) == 0
+}
+
+// DiffDeliveryServiceInstanceBodies lists every field that differs between the normalized forms of old and new,
+// identified by the JSON path of the field
+func DiffDeliveryServiceInstanceBodies(old *DeliveryServiceInstanceBody, new *DeliveryServiceInstanceBody) []BodyDifference {
+	oldFields := map[string]interface{}{}
+	newFields := map[string]interface{}{}
+	flattenBody(Normalize(old), oldFields)
+	flattenBody(Normalize(new), newFields)
+
+	var differences []BodyDifference
+	for path, oldValue := range oldFields {
+		newValue, ok := newFields[path]
+		if !ok {
+			differences = append(differences, BodyDifference{Path: path, Old: oldValue})
+		} else if !reflect.DeepEqual(oldValue, newValue) {
+			differences = append(differences, BodyDifference{Path: path, Old: oldValue, New: newValue})
+		}
+	}
+	for path, newValue := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			differences = append(differences, BodyDifference{Path: path, New: newValue})
+		}
+	}
+
+	sort.Slice(differences, func(i, j int) bool {
+		return differences[i].Path < differences[j].Path
+	})
+	return differences
+}
+
+func flattenBody(body *DeliveryServiceInstanceBody, fields map[string]interface{}) {
+	jsonBody, _ := json.Marshal(body)
+	var tree interface{}
+	json.Unmarshal(jsonBody, &tree)
+	flattenTree("", normalizeParameter(tree), fields)
+}
+
+func flattenTree(path string, tree interface{}, fields map[string]interface{}) {
+	switch value := tree.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if path == "" {
+				flattenTree(key, item, fields)
+			} else {
+				flattenTree(path+"."+key, item, fields)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			flattenTree(fmt.Sprintf("%s[%d]", path, i), item, fields)
+		}
+	default:
+		fields[path] = value
+	}
+}
+
+func formatDifferenceValue(value interface{}) string {
+	if value == nil {
+		return "(none)"
+	}
+	if text, ok := value.(string); ok {
+		return fmt.Sprintf("%q", text)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,112 @@
+package configuration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func intPointer(value int) *int {
+	return &value
+}
+
+func TestNormalize(t *testing.T) {
+	body := &DeliveryServiceInstanceBody{
+		PublishedHostname: "WWW.Example.com",
+		SourceHostname:    "Origin.Example.com",
+		ProtocolSets: []ProtocolSet{
+			{PublishedProtocol: "https", SourceProtocol: "https", Options: []Option{
+				{Name: "refresh_absmin", Parameters: []interface{}{60.0}},
+				{Name: "compress", Parameters: []interface{}{[]interface{}{1.0, 2.5}}},
+			}},
+			{PublishedProtocol: "http", SourceProtocol: "http", SourcePort: intPointer(8080), Options: []Option{}},
+		},
+	}
+	want := &DeliveryServiceInstanceBody{
+		PublishedHostname: "www.example.com",
+		SourceHostname:    "origin.example.com",
+		PublishedURLPath:  "/",
+		SourceURLPath:     "/",
+		ProtocolSets: []ProtocolSet{
+			{PublishedProtocol: "HTTP", SourceProtocol: "HTTP", SourcePort: intPointer(8080)},
+			{PublishedProtocol: "HTTPS", SourceProtocol: "HTTPS", SourcePort: intPointer(443), Options: []Option{
+				{Name: "compress", Parameters: []interface{}{[]interface{}{1, 2.5}}},
+				{Name: "refresh_absmin", Parameters: []interface{}{60}},
+			}},
+		},
+	}
+
+	if got := Normalize(body); !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize() = %+v, want %+v", got, want)
+	}
+	if parameter := body.ProtocolSets[0].Options[1].Parameters[0]; !reflect.DeepEqual(parameter, []interface{}{1.0, 2.5}) {
+		t.Errorf("Normalize() modified its input parameter to %#v", parameter)
+	}
+	if body.ProtocolSets[1].SourcePort == nil || *body.ProtocolSets[1].SourcePort != 8080 || body.PublishedURLPath != "" {
+		t.Errorf("Normalize() modified its input body to %+v", body)
+	}
+}
+
+func TestDiffDeliveryServiceInstanceBodies(t *testing.T) {
+	base := func() *DeliveryServiceInstanceBody {
+		return &DeliveryServiceInstanceBody{
+			PublishedHostname: "www.example.com",
+			SourceHostname:    "origin.example.com",
+			ProtocolSets: []ProtocolSet{
+				{PublishedProtocol: "HTTPS", SourceProtocol: "HTTPS", Options: []Option{
+					{Name: "refresh_absmin", Parameters: []interface{}{60}},
+				}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(body *DeliveryServiceInstanceBody)
+		want   []BodyDifference
+	}{
+		{
+			name:   "identical",
+			modify: func(body *DeliveryServiceInstanceBody) {},
+		},
+		{
+			name: "equal once normalized",
+			modify: func(body *DeliveryServiceInstanceBody) {
+				body.PublishedHostname = "WWW.EXAMPLE.COM"
+				body.PublishedURLPath = "/"
+				body.ProtocolSets[0].PublishedProtocol = "https"
+				body.ProtocolSets[0].SourcePort = intPointer(443)
+				body.ProtocolSets[0].Options[0].Parameters = []interface{}{60.0}
+			},
+		},
+		{
+			name:   "changed hostname",
+			modify: func(body *DeliveryServiceInstanceBody) { body.SourceHostname = "new.example.com" },
+			want:   []BodyDifference{{Path: "sourceHostname", Old: "origin.example.com", New: "new.example.com"}},
+		},
+		{
+			name:   "changed parameter",
+			modify: func(body *DeliveryServiceInstanceBody) { body.ProtocolSets[0].Options[0].Parameters[0] = 120 },
+			want:   []BodyDifference{{Path: "protocolSets[0].options[0].parameters[0]", Old: 60, New: 120}},
+		},
+		{
+			name: "added parameter",
+			modify: func(body *DeliveryServiceInstanceBody) {
+				body.ProtocolSets[0].Options[0].Parameters = append(body.ProtocolSets[0].Options[0].Parameters, 5)
+			},
+			want: []BodyDifference{{Path: "protocolSets[0].options[0].parameters[1]", New: 5}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			modified := base()
+			test.modify(modified)
+			got := DiffDeliveryServiceInstanceBodies(base(), modified)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("DiffDeliveryServiceInstanceBodies() = %v, want %v", got, test.want)
+			}
+			if equal := DeliveryServiceInstanceBodiesEqual(base(), modified); equal != (len(test.want) == 0) {
+				t.Errorf("DeliveryServiceInstanceBodiesEqual() = %v, want %v", equal, len(test.want) == 0)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package configuration
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
 )
 
 const (
@@ -23,6 +24,26 @@ type RealtimeStreamingSlot struct {
 	MediaVaultSecretKey string                     `json:"mediaVaultSecretKey,omitempty"`
 }
 
+// RealtimeStreamingSlotUpdate changes the settings of an existing slot. Nil fields are left unchanged.
+type RealtimeStreamingSlotUpdate struct {
+	Profiles            []RealtimeStreamingProfile `json:"profiles,omitempty"`
+	Password            *string                    `json:"password,omitempty"`
+	IPGeoMatch          *string                    `json:"ipGeoMatch,omitempty"`
+	MediaVaultEnabled   *bool                      `json:"mediaVaultEnabled,omitempty"`
+	MediaVaultSecretKey *string                    `json:"mediaVaultSecretKey,omitempty"`
+}
+
+// RealtimeStreamingSlotFilter restricts ListFilteredRealtimeStreamingSlots to the slots in the said state and region.
+// Empty fields match every slot.
+type RealtimeStreamingSlotFilter struct {
+	State  string
+	Region string
+}
+
+type RealtimeStreamingSlotsResponse struct {
+	Slots []RealtimeStreamingSlot `json:"slots"`
+}
+
 type RealtimeStreamingProfile struct {
 	VideoBitrate int `json:"videoBitrate"`
 	AudioBitrate int `json:"audioBitrate"`
@@ -43,6 +64,44 @@ func (c *ConfigurationClient) GetRealtimeStreamingSlot(slotId string, shortname
 	return realtimeStreamingSlot, response, nil
 }
 
+func (c *ConfigurationClient) ListRealtimeStreamingSlots(shortname string) ([]RealtimeStreamingSlot, *http.Response, error) {
+	return c.ListFilteredRealtimeStreamingSlots(shortname, nil)
+}
+
+// ListFilteredRealtimeStreamingSlots lists the slots of the shortname matched by filter, which may be nil
+func (c *ConfigurationClient) ListFilteredRealtimeStreamingSlots(shortname string, filter *RealtimeStreamingSlotFilter) ([]RealtimeStreamingSlot, *http.Response, error) {
+	<-c.rateLimiter
+	query := url.Values{}
+	if filter != nil && filter.State != "" {
+		query.Set("state", filter.State)
+	}
+	if filter != nil && filter.Region != "" {
+		query.Set("region", filter.Region)
+	}
+	listUrl := c.BaseUrl + "/webrtc/shortname/" + shortname + "/slots"
+	if len(query) > 0 {
+		listUrl += "?" + query.Encode()
+	}
+
+	body, response, err := c.Auth.HTTPGet(listUrl)
+
+	if err != nil {
+		return nil, response, err
+	}
+
+	realtimeStreamingSlotsResponse := &RealtimeStreamingSlotsResponse{}
+	json.Unmarshal(body, realtimeStreamingSlotsResponse)
+
+	var slots []RealtimeStreamingSlot
+	for _, slot := range realtimeStreamingSlotsResponse.Slots {
+		if filter == nil || (filter.State == "" || slot.State == filter.State) && (filter.Region == "" || slot.Region == filter.Region) {
+			slots = append(slots, slot)
+		}
+	}
+
+	return slots, response, nil
+}
+
 func (c *ConfigurationClient) CreateRealtimeStreamingSlot(shortname string, slot *RealtimeStreamingSlot) (*RealtimeStreamingSlot, *http.Response, error) {
 	<-c.rateLimiter
 
@@ -60,6 +119,23 @@ func (c *ConfigurationClient) CreateRealtimeStreamingSlot(shortname string, slot
 	return responseSlot, response, nil
 }
 
+func (c *ConfigurationClient) UpdateRealtimeStreamingSlot(slotId string, shortname string, update *RealtimeStreamingSlotUpdate) (*RealtimeStreamingSlot, *http.Response, error) {
+	<-c.rateLimiter
+
+	jsonRequest, _ := json.Marshal(update)
+
+	body, response, err := c.Auth.HTTPPut(c.BaseUrl+"/webrtc/shortname/"+shortname+"/slots/"+slotId, string(jsonRequest))
+
+	if err != nil {
+		return nil, response, err
+	}
+
+	responseSlot := &RealtimeStreamingSlot{}
+	json.Unmarshal(body, responseSlot)
+
+	return responseSlot, response, nil
+}
+
 func (c *ConfigurationClient) DeleteRealtimeStreamingSlot(slotId string, shortname string) (*http.Response, error) {
 	<-c.rateLimiter
 	_, response, err := c.Auth.HTTPDelete(c.BaseUrl + "/webrtc/shortname/" + shortname + "/slots/" + slotId)
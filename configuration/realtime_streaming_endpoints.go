@@ -0,0 +1,142 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/llnw/llnw-sdk-go/mediavault"
+)
+
+// Default endpoint templates of realtime streaming slots. {shortname}, {region}, {slot} and {name} are replaced by
+// the shortname and the slot's region, id and name, each escaped for use in a URL path.
+const (
+	DefaultRealtimeStreamingIngestURLTemplate   = "rtmps://{region}.ingest.rts.llnwd.net/{shortname}"
+	DefaultRealtimeStreamingPlaybackURLTemplate = "https://{shortname}.rts.llnwd.net/{slot}/index.m3u8"
+)
+
+// RealtimeStreamingEndpointOptions customises the endpoints derived by RealtimeStreamingSlot.Endpoints. Empty
+// templates use the defaults, and MediaVault restricts the signed playback URL of slots with MediaVault enabled. Its
+// Expiry is required for those slots, as a signed URL without one could be shared forever.
+type RealtimeStreamingEndpointOptions struct {
+	IngestURLTemplate   string
+	PlaybackURLTemplate string
+	MediaVault          mediavault.Options
+}
+
+// RealtimeStreamingEndpoints is everything a broadcaster and a player need to use a ready slot
+type RealtimeStreamingEndpoints struct {
+	SlotId   string                    `json:"slotId"`
+	Name     string                    `json:"name"`
+	Region   string                    `json:"region"`
+	Ingest   RealtimeStreamingIngest   `json:"ingest"`
+	Playback RealtimeStreamingPlayback `json:"playback"`
+}
+
+// RealtimeStreamingIngest is where and how a broadcaster publishes to a slot
+type RealtimeStreamingIngest struct {
+	URL       string `json:"url"`
+	Server    string `json:"server"`
+	StreamKey string `json:"streamKey"`
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+}
+
+// RealtimeStreamingPlayback is where viewers watch a slot. URL is signed when the slot has MediaVault enabled.
+type RealtimeStreamingPlayback struct {
+	URL    string `json:"url"`
+	Signed bool   `json:"signed"`
+}
+
+// Endpoints derives the ingest and playback endpoints of a ready slot of the said shortname. options may be nil.
+func (s *RealtimeStreamingSlot) Endpoints(shortname string, options *RealtimeStreamingEndpointOptions) (*RealtimeStreamingEndpoints, error) {
+	if s.State != SlotStateReady {
+		return nil, fmt.Errorf("realtime streaming slot %s is %s, not %s", s.Id, s.State, SlotStateReady)
+	}
+	if options == nil {
+		options = &RealtimeStreamingEndpointOptions{}
+	}
+	ingestTemplate := options.IngestURLTemplate
+	if ingestTemplate == "" {
+		ingestTemplate = DefaultRealtimeStreamingIngestURLTemplate
+	}
+	playbackTemplate := options.PlaybackURLTemplate
+	if playbackTemplate == "" {
+		playbackTemplate = DefaultRealtimeStreamingPlaybackURLTemplate
+	}
+
+	replacer := strings.NewReplacer(
+		"{shortname}", url.PathEscape(shortname),
+		"{region}", url.PathEscape(strings.ToLower(s.Region)),
+		"{slot}", url.PathEscape(s.Id),
+		"{name}", url.PathEscape(s.Name),
+	)
+	server := replacer.Replace(ingestTemplate)
+	endpoints := &RealtimeStreamingEndpoints{
+		SlotId: s.Id,
+		Name:   s.Name,
+		Region: s.Region,
+		Ingest: RealtimeStreamingIngest{
+			URL:       strings.TrimSuffix(server, "/") + "/" + url.PathEscape(s.Id),
+			Server:    server,
+			StreamKey: s.Id,
+		},
+		Playback: RealtimeStreamingPlayback{URL: replacer.Replace(playbackTemplate)},
+	}
+	if s.Password != "" {
+		endpoints.Ingest.Username, endpoints.Ingest.Password = s.Name, s.Password
+	}
+
+	if s.MediaVaultEnabled {
+		if s.MediaVaultSecretKey == "" {
+			return nil, fmt.Errorf("realtime streaming slot %s has MediaVault enabled but no secret key", s.Id)
+		}
+		if options.MediaVault.Expiry.IsZero() {
+			return nil, fmt.Errorf("realtime streaming slot %s has MediaVault enabled, its playback URL needs an expiry", s.Id)
+		}
+		signed, err := mediavault.Sign(endpoints.Playback.URL, s.MediaVaultSecretKey, options.MediaVault)
+		if err != nil {
+			return nil, err
+		}
+		endpoints.Playback.URL, endpoints.Playback.Signed = signed, true
+	}
+	return endpoints, nil
+}
+
+// WriteJSON writes the endpoints as indented JSON
+func (e *RealtimeStreamingEndpoints) WriteJSON(w io.Writer) error {
+	return writeIndentedJSON(w, e)
+}
+
+// WriteOBSService writes the ingest endpoint as an OBS Studio service.json for a custom RTMP server
+func (e *RealtimeStreamingEndpoints) WriteOBSService(w io.Writer) error {
+	type obsSettings struct {
+		Server   string `json:"server"`
+		Key      string `json:"key"`
+		UseAuth  bool   `json:"use_auth"`
+		Username string `json:"username,omitempty"`
+		Password string `json:"password,omitempty"`
+	}
+	return writeIndentedJSON(w, struct {
+		Type     string      `json:"type"`
+		Settings obsSettings `json:"settings"`
+	}{
+		Type: "rtmp_custom",
+		Settings: obsSettings{
+			Server:   e.Ingest.Server,
+			Key:      e.Ingest.StreamKey,
+			UseAuth:  e.Ingest.Password != "",
+			Username: e.Ingest.Username,
+			Password: e.Ingest.Password,
+		},
+	})
+}
+
+func writeIndentedJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
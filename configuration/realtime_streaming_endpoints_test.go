@@ -0,0 +1,42 @@
+package configuration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/llnw/llnw-sdk-go/mediavault"
+)
+
+func TestRealtimeStreamingSlotEndpoints(t *testing.T) {
+	slot := &RealtimeStreamingSlot{Id: "slot-1", State: SlotStateReady, Name: "main", Region: "NA", Password: "pw"}
+	endpoints, err := slot.Endpoints("acct", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoints.Ingest.URL != "rtmps://na.ingest.rts.llnwd.net/acct/slot-1" || endpoints.Ingest.Username != "main" ||
+		endpoints.Playback.URL != "https://acct.rts.llnwd.net/slot-1/index.m3u8" || endpoints.Playback.Signed {
+		t.Errorf("Endpoints() = %+v", endpoints)
+	}
+
+	slot.MediaVaultEnabled, slot.MediaVaultSecretKey = true, "secret"
+	if _, err := slot.Endpoints("acct", nil); err == nil {
+		t.Error("Endpoints() signed a playback URL without an expiry")
+	}
+
+	expiry := time.Now().Add(time.Hour)
+	endpoints, err = slot.Endpoints("acct", &RealtimeStreamingEndpointOptions{MediaVault: mediavault.Options{Expiry: expiry}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !endpoints.Playback.Signed {
+		t.Errorf("playback URL %s is not signed", endpoints.Playback.URL)
+	}
+	verifier := mediavault.NewVerifier("secret")
+	if err := verifier.Verify(endpoints.Playback.URL, nil); err != nil {
+		t.Errorf("Verify(%s) = %v", endpoints.Playback.URL, err)
+	}
+	verifier.Now = func() time.Time { return expiry }
+	if err := verifier.Verify(endpoints.Playback.URL, nil); err != mediavault.ErrExpired {
+		t.Errorf("Verify(%s) at expiry = %v, want %v", endpoints.Playback.URL, err, mediavault.ErrExpired)
+	}
+}
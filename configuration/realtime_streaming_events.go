@@ -0,0 +1,260 @@
+package configuration
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults used by ProvisionRealtimeStreamingEvent when RealtimeStreamingEventOptions leaves them unset
+const (
+	DefaultRealtimeStreamingEventConcurrency  = 4
+	DefaultRealtimeStreamingEventReadyTimeout = 10 * time.Minute
+	DefaultRealtimeStreamingEventPollInterval = 10 * time.Second
+	DefaultSecretLength                       = 32
+)
+
+// secretAlphabet holds the characters of generated secrets, which are safe in URLs and configuration files
+const secretAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// RealtimeStreamingEventTemplate describes the slots of an event. NamePattern must contain {n}, which is replaced by
+// the slot's number from 1, zero padded to the width of Count. Every slot gets a generated password, and a generated
+// MediaVault secret key when MediaVaultEnabled is set.
+type RealtimeStreamingEventTemplate struct {
+	NamePattern       string
+	Count             int
+	Region            string
+	Profiles          []RealtimeStreamingProfile
+	IPGeoMatch        string
+	MediaVaultEnabled bool
+}
+
+// RealtimeStreamingEventOptions controls how the slots of an event are provisioned. With KeepPartial set, the slots
+// created before a failure are kept instead of being deleted.
+type RealtimeStreamingEventOptions struct {
+	Concurrency  int
+	ReadyTimeout time.Duration
+	PollInterval time.Duration
+	KeepPartial  bool
+}
+
+// RealtimeStreamingEventManifest lists the slots provisioned for an event, including their generated credentials
+type RealtimeStreamingEventManifest struct {
+	Shortname string                  `json:"shortname"`
+	CreatedAt time.Time               `json:"createdAt"`
+	Slots     []RealtimeStreamingSlot `json:"slots"`
+}
+
+// GenerateSecret returns a cryptographically random string of the said length, or of DefaultSecretLength if length
+// is not positive, made of letters and digits
+func GenerateSecret(length int) (string, error) {
+	if length <= 0 {
+		length = DefaultSecretLength
+	}
+	alphabetSize := big.NewInt(int64(len(secretAlphabet)))
+	secret := make([]byte, length)
+	for i := range secret {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		secret[i] = secretAlphabet[n.Int64()]
+	}
+	return string(secret), nil
+}
+
+// Slots builds the slots described by the template, with freshly generated credentials
+func (t *RealtimeStreamingEventTemplate) Slots() ([]RealtimeStreamingSlot, error) {
+	if t.Count <= 0 {
+		return nil, fmt.Errorf("an event needs at least one slot, got %d", t.Count)
+	}
+	if !strings.Contains(t.NamePattern, "{n}") {
+		return nil, fmt.Errorf("name pattern %q does not contain {n}", t.NamePattern)
+	}
+	if err := ValidateRealtimeStreamingProfiles(t.Profiles, nil); err != nil {
+		return nil, err
+	}
+	if t.IPGeoMatch != "" {
+		if _, err := ParseIPGeoMatch(t.IPGeoMatch); err != nil {
+			return nil, err
+		}
+	}
+
+	width := len(strconv.Itoa(t.Count))
+	slots := make([]RealtimeStreamingSlot, t.Count)
+	for i := range slots {
+		password, err := GenerateSecret(0)
+		if err != nil {
+			return nil, err
+		}
+		slots[i] = RealtimeStreamingSlot{
+			Name:              strings.Replace(t.NamePattern, "{n}", fmt.Sprintf("%0*d", width, i+1), -1),
+			Region:            t.Region,
+			Profiles:          append([]RealtimeStreamingProfile(nil), t.Profiles...),
+			Password:          password,
+			IPGeoMatch:        t.IPGeoMatch,
+			MediaVaultEnabled: t.MediaVaultEnabled,
+		}
+		if t.MediaVaultEnabled {
+			if slots[i].MediaVaultSecretKey, err = GenerateSecret(0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return slots, nil
+}
+
+// ProvisionRealtimeStreamingEvent creates every slot of the template concurrently, then waits until all of them are
+// ready. If any slot cannot be created, fails or is not ready in time, the slots already created are deleted, unless
+// options.KeepPartial is set, and the returned manifest lists the slots left behind. options may be nil.
+func (c *ConfigurationClient) ProvisionRealtimeStreamingEvent(shortname string, template *RealtimeStreamingEventTemplate, options *RealtimeStreamingEventOptions) (*RealtimeStreamingEventManifest, error) {
+	if options == nil {
+		options = &RealtimeStreamingEventOptions{}
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultRealtimeStreamingEventConcurrency
+	}
+
+	slots, err := template.Slots()
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]*RealtimeStreamingSlot, len(slots))
+	createErrors := make([]error, len(slots))
+	c.forEachConcurrently(len(slots), concurrency, func(i int) {
+		createdSlot, _, err := c.CreateRealtimeStreamingSlot(shortname, &slots[i])
+		if err != nil {
+			createErrors[i] = fmt.Errorf("creating realtime streaming slot %s: %s", slots[i].Name, err)
+			return
+		}
+		if createdSlot.Id == "" {
+			// without an id the slot can neither be polled nor deleted
+			createErrors[i] = fmt.Errorf("creating realtime streaming slot %s: the response has no id", slots[i].Name)
+			return
+		}
+		// keep the generated secrets in case the response leaves them out
+		createdSlot.Password, createdSlot.MediaVaultSecretKey = slots[i].Password, slots[i].MediaVaultSecretKey
+		created[i] = createdSlot
+	})
+
+	manifest := &RealtimeStreamingEventManifest{Shortname: shortname, CreatedAt: time.Now().UTC()}
+	for _, createdSlot := range created {
+		if createdSlot != nil {
+			manifest.Slots = append(manifest.Slots, *createdSlot)
+		}
+	}
+
+	err = joinErrors(createErrors)
+	if err == nil {
+		err = c.waitForRealtimeStreamingSlots(manifest, options)
+	}
+	if err == nil || options.KeepPartial {
+		return manifest, err
+	}
+
+	remaining, teardownErr := c.teardownRealtimeStreamingSlots(shortname, manifest.Slots, concurrency)
+	manifest.Slots = remaining
+	if teardownErr != nil {
+		return manifest, fmt.Errorf("%s; cleaning up: %s", err, teardownErr)
+	}
+	return manifest, err
+}
+
+// TeardownRealtimeStreamingEvent deletes every slot of the manifest. Slots that no longer exist are ignored.
+func (c *ConfigurationClient) TeardownRealtimeStreamingEvent(manifest *RealtimeStreamingEventManifest) error {
+	_, err := c.teardownRealtimeStreamingSlots(manifest.Shortname, manifest.Slots, DefaultRealtimeStreamingEventConcurrency)
+	return err
+}
+
+// waitForRealtimeStreamingSlots polls the slots of the manifest, updating their state, until all of them are ready
+func (c *ConfigurationClient) waitForRealtimeStreamingSlots(manifest *RealtimeStreamingEventManifest, options *RealtimeStreamingEventOptions) error {
+	timeout := options.ReadyTimeout
+	if timeout <= 0 {
+		timeout = DefaultRealtimeStreamingEventReadyTimeout
+	}
+	interval := options.PollInterval
+	if interval <= 0 {
+		interval = DefaultRealtimeStreamingEventPollInterval
+	}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		listed, _, err := c.ListRealtimeStreamingSlots(manifest.Shortname)
+		if err != nil {
+			return fmt.Errorf("listing realtime streaming slots: %s", err)
+		}
+		states := map[string]string{}
+		for _, slot := range listed {
+			states[slot.Id] = slot.State
+		}
+
+		var pending, failed []string
+		for i := range manifest.Slots {
+			slot := &manifest.Slots[i]
+			if state, ok := states[slot.Id]; ok {
+				slot.State = state
+			}
+			switch slot.State {
+			case SlotStateReady:
+			case SlotStateFailed:
+				failed = append(failed, slot.Name)
+			default:
+				pending = append(pending, slot.Name)
+			}
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("realtime streaming slots failed: %s", strings.Join(failed, ", "))
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("realtime streaming slots not ready after %s: %s", timeout, strings.Join(pending, ", "))
+		}
+		time.Sleep(interval)
+	}
+}
+
+// teardownRealtimeStreamingSlots deletes the slots concurrently and returns those that could not be deleted. Slots
+// without an id are skipped, as deleting them would address the collection rather than a slot.
+func (c *ConfigurationClient) teardownRealtimeStreamingSlots(shortname string, slots []RealtimeStreamingSlot, concurrency int) ([]RealtimeStreamingSlot, error) {
+	deleteErrors := make([]error, len(slots))
+	c.forEachConcurrently(len(slots), concurrency, func(i int) {
+		if slots[i].Id == "" {
+			return
+		}
+		response, err := c.DeleteRealtimeStreamingSlot(slots[i].Id, shortname)
+		if err != nil && (response == nil || response.StatusCode != http.StatusNotFound) {
+			deleteErrors[i] = fmt.Errorf("deleting realtime streaming slot %s: %s", slots[i].Name, err)
+		}
+	})
+
+	var remaining []RealtimeStreamingSlot
+	for i, err := range deleteErrors {
+		if err != nil {
+			remaining = append(remaining, slots[i])
+		}
+	}
+	return remaining, joinErrors(deleteErrors)
+}
+
+func joinErrors(errs []error) error {
+	var messages []string
+	for _, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
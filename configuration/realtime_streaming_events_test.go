@@ -0,0 +1,64 @@
+package configuration
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProvisionRealtimeStreamingEventRejectsSlotsWithoutId(t *testing.T) {
+	var lock sync.Mutex
+	var deleted []string
+	client, stop := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			slot := RealtimeStreamingSlot{}
+			json.NewDecoder(r.Body).Decode(&slot)
+			if slot.Name == "stage-1" {
+				slot.Id = "slot-1"
+			}
+			json.NewEncoder(w).Encode(slot)
+		case http.MethodDelete:
+			lock.Lock()
+			deleted = append(deleted, r.URL.Path)
+			lock.Unlock()
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer stop()
+
+	template := &RealtimeStreamingEventTemplate{NamePattern: "stage-{n}", Count: 2, Region: "NA", Profiles: RealtimeStreamingLadderSD()}
+	manifest, err := client.ProvisionRealtimeStreamingEvent("acct", template, &RealtimeStreamingEventOptions{ReadyTimeout: time.Second})
+	if err == nil || !strings.Contains(err.Error(), "stage-2: the response has no id") {
+		t.Fatalf("ProvisionRealtimeStreamingEvent() error = %v", err)
+	}
+	if len(manifest.Slots) != 0 {
+		t.Errorf("slots left behind: %+v", manifest.Slots)
+	}
+	sort.Strings(deleted)
+	if len(deleted) != 1 || deleted[0] != "/webrtc/shortname/acct/slots/slot-1" {
+		t.Errorf("deleted %v", deleted)
+	}
+}
+
+func TestTeardownRealtimeStreamingEventSkipsSlotsWithoutId(t *testing.T) {
+	var deleted []string
+	client, stop := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deleted = append(deleted, r.URL.Path)
+	}))
+	defer stop()
+
+	manifest := &RealtimeStreamingEventManifest{Shortname: "acct", Slots: []RealtimeStreamingSlot{{Name: "stage-1"}, {Id: "slot-2", Name: "stage-2"}}}
+	if err := client.TeardownRealtimeStreamingEvent(manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0] != "/webrtc/shortname/acct/slots/slot-2" {
+		t.Errorf("deleted %v", deleted)
+	}
+}
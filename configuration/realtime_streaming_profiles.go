@@ -0,0 +1,153 @@
+package configuration
+
+import (
+	"fmt"
+)
+
+// RealtimeStreamingLadderSD returns a standard definition ladder. Bitrates of every preset are in kbps, highest
+// quality first, and each call returns a new slice.
+func RealtimeStreamingLadderSD() []RealtimeStreamingProfile {
+	return []RealtimeStreamingProfile{
+		{VideoBitrate: 1200, AudioBitrate: 128},
+		{VideoBitrate: 800, AudioBitrate: 96},
+		{VideoBitrate: 400, AudioBitrate: 64},
+	}
+}
+
+// RealtimeStreamingLadderHD returns a high definition ladder topped at 1080p
+func RealtimeStreamingLadderHD() []RealtimeStreamingProfile {
+	return []RealtimeStreamingProfile{
+		{VideoBitrate: 4500, AudioBitrate: 128},
+		{VideoBitrate: 2500, AudioBitrate: 128},
+		{VideoBitrate: 1200, AudioBitrate: 96},
+		{VideoBitrate: 600, AudioBitrate: 64},
+	}
+}
+
+// RealtimeStreamingLadderLowLatencyMobile returns a ladder for mobile viewers on constrained networks
+func RealtimeStreamingLadderLowLatencyMobile() []RealtimeStreamingProfile {
+	return []RealtimeStreamingProfile{
+		{VideoBitrate: 1000, AudioBitrate: 96},
+		{VideoBitrate: 500, AudioBitrate: 64},
+		{VideoBitrate: 250, AudioBitrate: 48},
+	}
+}
+
+// RealtimeStreamingLadderLimits bounds the profiles accepted by ValidateRealtimeStreamingProfiles. Bitrates are in
+// kbps and MaxAudioVideoRatio is the largest audio bitrate allowed as a fraction of the video bitrate.
+type RealtimeStreamingLadderLimits struct {
+	MaxProfiles        int
+	MinVideoBitrate    int
+	MaxVideoBitrate    int
+	MinAudioBitrate    int
+	MaxAudioBitrate    int
+	MaxAudioVideoRatio float64
+}
+
+// DefaultRealtimeStreamingLadderLimits are the limits used when none are given
+var DefaultRealtimeStreamingLadderLimits = RealtimeStreamingLadderLimits{
+	MaxProfiles:        6,
+	MinVideoBitrate:    100,
+	MaxVideoBitrate:    20000,
+	MinAudioBitrate:    32,
+	MaxAudioBitrate:    320,
+	MaxAudioVideoRatio: 0.5,
+}
+
+type realtimeStreamingRung struct {
+	height  int
+	profile RealtimeStreamingProfile
+}
+
+// realtimeStreamingRungs lists a profile for each common resolution, tallest first
+var realtimeStreamingRungs = []realtimeStreamingRung{
+	{height: 2160, profile: RealtimeStreamingProfile{VideoBitrate: 16000, AudioBitrate: 192}},
+	{height: 1440, profile: RealtimeStreamingProfile{VideoBitrate: 9000, AudioBitrate: 160}},
+	{height: 1080, profile: RealtimeStreamingProfile{VideoBitrate: 4500, AudioBitrate: 128}},
+	{height: 720, profile: RealtimeStreamingProfile{VideoBitrate: 2500, AudioBitrate: 128}},
+	{height: 540, profile: RealtimeStreamingProfile{VideoBitrate: 1200, AudioBitrate: 96}},
+	{height: 360, profile: RealtimeStreamingProfile{VideoBitrate: 800, AudioBitrate: 96}},
+	{height: 240, profile: RealtimeStreamingProfile{VideoBitrate: 400, AudioBitrate: 64}},
+}
+
+// ValidateRealtimeStreamingProfiles checks that the profiles are ordered from the highest to the lowest video
+// bitrate without duplicates, and that every bitrate is within limits. A nil limits uses
+// DefaultRealtimeStreamingLadderLimits. Every problem is returned as ValidationErrors.
+func ValidateRealtimeStreamingProfiles(profiles []RealtimeStreamingProfile, limits *RealtimeStreamingLadderLimits) error {
+	if limits == nil {
+		limits = &DefaultRealtimeStreamingLadderLimits
+	}
+
+	var validationErrors ValidationErrors
+	if len(profiles) == 0 {
+		validationErrors = append(validationErrors, ValidationError{Path: "profiles", Message: "at least one profile is required"})
+	}
+	if limits.MaxProfiles > 0 && len(profiles) > limits.MaxProfiles {
+		validationErrors = append(validationErrors, ValidationError{
+			Path:    "profiles",
+			Message: fmt.Sprintf("at most %d profiles are allowed, got %d", limits.MaxProfiles, len(profiles)),
+		})
+	}
+
+	for i, profile := range profiles {
+		path := fmt.Sprintf("profiles[%d]", i)
+		if i > 0 {
+			previous := profiles[i-1]
+			if profile == previous {
+				validationErrors = append(validationErrors, ValidationError{Path: path, Message: "duplicates the previous profile"})
+			} else if profile.VideoBitrate >= previous.VideoBitrate {
+				validationErrors = append(validationErrors, ValidationError{
+					Path:    path + ".videoBitrate",
+					Message: fmt.Sprintf("profiles must be ordered from highest to lowest video bitrate, %d follows %d", profile.VideoBitrate, previous.VideoBitrate),
+				})
+			}
+		}
+		if message := checkBitrate(profile.VideoBitrate, limits.MinVideoBitrate, limits.MaxVideoBitrate); message != "" {
+			validationErrors = append(validationErrors, ValidationError{Path: path + ".videoBitrate", Message: message})
+		}
+		if message := checkBitrate(profile.AudioBitrate, limits.MinAudioBitrate, limits.MaxAudioBitrate); message != "" {
+			validationErrors = append(validationErrors, ValidationError{Path: path + ".audioBitrate", Message: message})
+		}
+		if limits.MaxAudioVideoRatio > 0 && profile.VideoBitrate > 0 && float64(profile.AudioBitrate)/float64(profile.VideoBitrate) > limits.MaxAudioVideoRatio {
+			validationErrors = append(validationErrors, ValidationError{
+				Path:    path,
+				Message: fmt.Sprintf("audio bitrate %d is more than %v of video bitrate %d", profile.AudioBitrate, limits.MaxAudioVideoRatio, profile.VideoBitrate),
+			})
+		}
+	}
+
+	if len(validationErrors) == 0 {
+		return nil
+	}
+	return validationErrors
+}
+
+// RealtimeStreamingProfilesForResolution builds a ladder topped by the tallest common resolution that fits within
+// maxHeight, for example 720 for a 1280x720 source, down to 240 lines. When that makes more profiles than
+// DefaultRealtimeStreamingLadderLimits allows, the rungs just below the top are dropped, so that the ladder keeps both
+// the source resolution and the low rungs viewers on slow connections depend on.
+func RealtimeStreamingProfilesForResolution(maxHeight int) ([]RealtimeStreamingProfile, error) {
+	var profiles []RealtimeStreamingProfile
+	for _, rung := range realtimeStreamingRungs {
+		if rung.height <= maxHeight {
+			profiles = append(profiles, rung.profile)
+		}
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no realtime streaming profile fits within %d lines, the minimum is %d", maxHeight, realtimeStreamingRungs[len(realtimeStreamingRungs)-1].height)
+	}
+	if excess := len(profiles) - DefaultRealtimeStreamingLadderLimits.MaxProfiles; excess > 0 {
+		profiles = append(profiles[:1], profiles[1+excess:]...)
+	}
+	return profiles, nil
+}
+
+func checkBitrate(bitrate int, min int, max int) string {
+	if min > 0 && bitrate < min {
+		return fmt.Sprintf("%d kbps is below the minimum of %d kbps", bitrate, min)
+	}
+	if max > 0 && bitrate > max {
+		return fmt.Sprintf("%d kbps is above the maximum of %d kbps", bitrate, max)
+	}
+	return ""
+}
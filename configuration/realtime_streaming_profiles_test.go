@@ -0,0 +1,40 @@
+package configuration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRealtimeStreamingProfilesForResolution(t *testing.T) {
+	tests := []struct {
+		maxHeight   int
+		wantBitrate []int
+	}{
+		// 1440p is dropped to stay within six profiles, keeping 4K on top and 240p at the bottom
+		{maxHeight: 2160, wantBitrate: []int{16000, 4500, 2500, 1200, 800, 400}},
+		{maxHeight: 1440, wantBitrate: []int{9000, 4500, 2500, 1200, 800, 400}},
+		{maxHeight: 1080, wantBitrate: []int{4500, 2500, 1200, 800, 400}},
+		{maxHeight: 800, wantBitrate: []int{2500, 1200, 800, 400}},
+		{maxHeight: 240, wantBitrate: []int{400}},
+	}
+	for _, test := range tests {
+		profiles, err := RealtimeStreamingProfilesForResolution(test.maxHeight)
+		if err != nil {
+			t.Fatalf("RealtimeStreamingProfilesForResolution(%d): %s", test.maxHeight, err)
+		}
+		var bitrates []int
+		for _, profile := range profiles {
+			bitrates = append(bitrates, profile.VideoBitrate)
+		}
+		if !reflect.DeepEqual(bitrates, test.wantBitrate) {
+			t.Errorf("RealtimeStreamingProfilesForResolution(%d) video bitrates = %v, want %v", test.maxHeight, bitrates, test.wantBitrate)
+		}
+		if err := ValidateRealtimeStreamingProfiles(profiles, nil); err != nil {
+			t.Errorf("RealtimeStreamingProfilesForResolution(%d) is not a valid ladder: %s", test.maxHeight, err)
+		}
+	}
+
+	if _, err := RealtimeStreamingProfilesForResolution(144); err == nil {
+		t.Error("RealtimeStreamingProfilesForResolution(144) succeeded, want an error")
+	}
+}
@@ -0,0 +1,64 @@
+package configuration
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/llnw/llnw-sdk-go/mediavault"
+)
+
+// SecretRotation is a secret of a slot replaced by a newly generated one at RotatedAt
+type SecretRotation struct {
+	SlotId    string
+	Old       string
+	New       string
+	RotatedAt time.Time
+}
+
+// Verifier returns a MediaVault verifier for the new secret that still accepts URLs signed with the old secret for
+// the said grace period after the rotation
+func (r *SecretRotation) Verifier(grace time.Duration) *mediavault.Verifier {
+	verifier := mediavault.NewVerifier(r.New)
+	if r.Old != "" && grace > 0 {
+		verifier.AcceptPreviousSecret(r.Old, r.RotatedAt.Add(grace))
+	}
+	return verifier
+}
+
+// RotateRealtimeStreamingSlotPassword replaces the password of the slot with a generated one
+func (c *ConfigurationClient) RotateRealtimeStreamingSlotPassword(slotId string, shortname string) (*SecretRotation, *http.Response, error) {
+	return c.rotateRealtimeStreamingSlotSecret(slotId, shortname, func(slot *RealtimeStreamingSlot) string {
+		return slot.Password
+	}, func(update *RealtimeStreamingSlotUpdate, secret string) {
+		update.Password = &secret
+	})
+}
+
+// RotateRealtimeStreamingSlotMediaVaultSecret replaces the MediaVault secret key of the slot with a generated one.
+// Playback URLs signed with the old key stop working unless they are checked with SecretRotation.Verifier.
+func (c *ConfigurationClient) RotateRealtimeStreamingSlotMediaVaultSecret(slotId string, shortname string) (*SecretRotation, *http.Response, error) {
+	return c.rotateRealtimeStreamingSlotSecret(slotId, shortname, func(slot *RealtimeStreamingSlot) string {
+		return slot.MediaVaultSecretKey
+	}, func(update *RealtimeStreamingSlotUpdate, secret string) {
+		update.MediaVaultSecretKey = &secret
+	})
+}
+
+func (c *ConfigurationClient) rotateRealtimeStreamingSlotSecret(slotId string, shortname string, current func(*RealtimeStreamingSlot) string, set func(*RealtimeStreamingSlotUpdate, string)) (*SecretRotation, *http.Response, error) {
+	slot, response, err := c.GetRealtimeStreamingSlot(slotId, shortname)
+	if err != nil {
+		return nil, response, fmt.Errorf("fetching realtime streaming slot %s: %s", slotId, err)
+	}
+
+	secret, err := GenerateSecret(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	update := &RealtimeStreamingSlotUpdate{}
+	set(update, secret)
+	if _, response, err = c.UpdateRealtimeStreamingSlot(slotId, shortname, update); err != nil {
+		return nil, response, err
+	}
+	return &SecretRotation{SlotId: slotId, Old: current(slot), New: secret, RotatedAt: time.Now()}, response, nil
+}
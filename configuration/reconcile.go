@@ -0,0 +1,200 @@
+package configuration
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	PlanActionCreate = "create"
+	PlanActionUpdate = "update"
+	PlanActionDelete = "delete"
+)
+
+// DeliveryServiceInstanceKey identifies a delivery service instance by where it is published
+type DeliveryServiceInstanceKey struct {
+	PublishedHostname string
+	PublishedURLPath  string
+}
+
+func (k DeliveryServiceInstanceKey) String() string {
+	return k.PublishedHostname + k.PublishedURLPath
+}
+
+// DeliveryServiceInstanceKeyOf returns the key of the said body
+func DeliveryServiceInstanceKeyOf(body *DeliveryServiceInstanceBody) DeliveryServiceInstanceKey {
+	key := DeliveryServiceInstanceKey{
+		PublishedHostname: strings.ToLower(body.PublishedHostname),
+		PublishedURLPath:  body.PublishedURLPath,
+	}
+	if key.PublishedURLPath == "" {
+		key.PublishedURLPath = "/"
+	}
+	return key
+}
+
+// PlanChange is a single create, update or delete in a Plan. Current is nil for creates and Desired is nil for
+// deletes.
+type PlanChange struct {
+	Action  string
+	Key     DeliveryServiceInstanceKey
+	UUID    string
+	Current *DeliveryServiceInstance
+	Desired *DeliveryServiceInstanceBody
+}
+
+// Differences lists the fields an update changes. Creates and deletes have no differences.
+func (c PlanChange) Differences() []BodyDifference {
+	if c.Action != PlanActionUpdate {
+		return nil
+	}
+	return DiffDeliveryServiceInstanceBodies(&c.Current.Body, c.Desired)
+}
+
+// Plan lists the changes needed to bring the delivery service instances of a shortname to the desired state
+type Plan struct {
+	Shortname string
+	Changes   []PlanChange
+}
+
+// IsEmpty reports whether live state already matches the desired state
+func (p *Plan) IsEmpty() bool {
+	return len(p.Changes) == 0
+}
+
+func (p *Plan) String() string {
+	var builder strings.Builder
+	counts := map[string]int{}
+	for _, change := range p.Changes {
+		counts[change.Action]++
+		if change.UUID != "" {
+			fmt.Fprintf(&builder, "%s %s (%s)\n", change.Action, change.Key, change.UUID)
+		} else {
+			fmt.Fprintf(&builder, "%s %s\n", change.Action, change.Key)
+		}
+		for _, difference := range change.Differences() {
+			fmt.Fprintf(&builder, "    %s\n", difference)
+		}
+	}
+	fmt.Fprintf(&builder, "Plan for %s: %d to create, %d to update, %d to delete\n",
+		p.Shortname, counts[PlanActionCreate], counts[PlanActionUpdate], counts[PlanActionDelete])
+	return builder.String()
+}
+
+// PlanDeliveryServiceInstanceChanges compares live instances with the desired bodies, matching them by
+// DeliveryServiceInstanceKey. Live instances with no desired body are only deleted when prune is set.
+func PlanDeliveryServiceInstanceChanges(shortname string, live []DeliveryServiceInstance, desired []DeliveryServiceInstanceBody, prune bool) (*Plan, error) {
+	liveByKey := map[DeliveryServiceInstanceKey]*DeliveryServiceInstance{}
+	for i := range live {
+		key := DeliveryServiceInstanceKeyOf(&live[i].Body)
+		if existing, ok := liveByKey[key]; ok {
+			return nil, fmt.Errorf("delivery service instances %s and %s are both published at %s", existing.UUID, live[i].UUID, key)
+		}
+		liveByKey[key] = &live[i]
+	}
+
+	desiredByKey := map[DeliveryServiceInstanceKey]*DeliveryServiceInstanceBody{}
+	for i := range desired {
+		key := DeliveryServiceInstanceKeyOf(&desired[i])
+		if _, ok := desiredByKey[key]; ok {
+			return nil, fmt.Errorf("more than one desired delivery service instance is published at %s", key)
+		}
+		desiredByKey[key] = &desired[i]
+	}
+
+	plan := &Plan{Shortname: shortname}
+	var creates, updates, deletes []PlanChange
+	for key, body := range desiredByKey {
+		current, ok := liveByKey[key]
+		if !ok {
+			creates = append(creates, PlanChange{Action: PlanActionCreate, Key: key, Desired: body})
+		} else if !DeliveryServiceInstanceBodiesEqual(&current.Body, body) {
+			updates = append(updates, PlanChange{Action: PlanActionUpdate, Key: key, UUID: current.UUID, Current: current, Desired: body})
+		}
+	}
+	if prune {
+		for key, current := range liveByKey {
+			if _, ok := desiredByKey[key]; !ok {
+				deletes = append(deletes, PlanChange{Action: PlanActionDelete, Key: key, UUID: current.UUID, Current: current})
+			}
+		}
+	}
+
+	for _, changes := range [][]PlanChange{creates, updates, deletes} {
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].Key.String() < changes[j].Key.String()
+		})
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	return plan, nil
+}
+
+// PlanDeliveryServiceInstances fetches the live delivery service instances of the shortname and plans the changes
+// needed to reach the desired bodies
+func (c *ConfigurationClient) PlanDeliveryServiceInstances(shortname string, desired []DeliveryServiceInstanceBody, prune bool) (*Plan, error) {
+	live, _, err := c.ListDeliveryServiceInstances(shortname)
+	if err != nil {
+		return nil, err
+	}
+	return PlanDeliveryServiceInstanceChanges(shortname, live, desired, prune)
+}
+
+// ApplyResult is the outcome of applying a single PlanChange
+type ApplyResult struct {
+	Change   PlanChange
+	Instance *DeliveryServiceInstance
+	Response *http.Response
+	Err      error
+}
+
+// ApplyReport holds the result of every change in an applied Plan
+type ApplyReport struct {
+	Results []ApplyResult
+}
+
+// Failed returns the results of the changes that could not be applied
+func (r *ApplyReport) Failed() []ApplyResult {
+	var failed []ApplyResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err summarises the failed changes, or returns nil if every change was applied
+func (r *ApplyReport) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	messages := make([]string, len(failed))
+	for i, result := range failed {
+		messages[i] = fmt.Sprintf("%s %s: %s", result.Change.Action, result.Change.Key, result.Err)
+	}
+	return fmt.Errorf("%d of %d changes failed: %s", len(failed), len(r.Results), strings.Join(messages, "; "))
+}
+
+// ApplyPlan applies every change in the plan, in order, through the Create, Update and Delete calls. Updated
+// instances keep their current owners. A failed change does not stop the remaining changes from being applied.
+func (c *ConfigurationClient) ApplyPlan(plan *Plan) *ApplyReport {
+	report := &ApplyReport{}
+	for _, change := range plan.Changes {
+		result := ApplyResult{Change: change}
+		switch change.Action {
+		case PlanActionCreate:
+			result.Instance, result.Response, result.Err = c.CreateDeliveryServiceInstance(change.Desired, plan.Shortname)
+		case PlanActionUpdate:
+			result.Instance, result.Response, result.Err = c.updateKeepingAccounts(plan.Shortname, change.Current, change.Desired)
+		case PlanActionDelete:
+			result.Instance, result.Response, result.Err = c.DeleteDeliveryServiceInstance(change.UUID)
+		default:
+			result.Err = fmt.Errorf("unknown plan action %q", change.Action)
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
@@ -0,0 +1,176 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	MigrationIssueUnsupported      = "unsupported"
+	MigrationIssueSignatureChanged = "signatureChanged"
+)
+
+type ServiceProfile struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type ServiceProfilesResponse struct {
+	Results []ServiceProfile `json:"results"`
+}
+
+// ProfileMigrationIssue describes an option of a body that is unsupported by the target service profile, or whose
+// signature differs there. Coercible is set when the parameters of a changed option are still valid for the target
+// profile once coerced.
+type ProfileMigrationIssue struct {
+	Path      string
+	Option    string
+	Kind      string
+	Message   string
+	Coercible bool
+}
+
+// ProfileMigrationAnalysis lists the issues found moving a body from one service profile to another
+type ProfileMigrationAnalysis struct {
+	SourceProfile string
+	TargetProfile string
+	Issues        []ProfileMigrationIssue
+}
+
+// Safe reports whether the body can be migrated automatically, that is when every option is supported by the target
+// profile and every changed signature is coercible
+func (a *ProfileMigrationAnalysis) Safe() bool {
+	for _, issue := range a.Issues {
+		if issue.Kind != MigrationIssueSignatureChanged || !issue.Coercible {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *ProfileMigrationAnalysis) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Migration from %s to %s: %d issues\n", a.SourceProfile, a.TargetProfile, len(a.Issues))
+	for _, issue := range a.Issues {
+		fmt.Fprintf(&builder, "%s: %s\n", issue.Path, issue.Message)
+	}
+	return builder.String()
+}
+
+func (c *ConfigurationClient) ListServiceProfiles(shortname string) ([]ServiceProfile, *http.Response, error) {
+	<-c.rateLimiter
+	body, response, err := c.Auth.HTTPGet(c.BaseUrl + "/svcprofile/shortname/" + shortname)
+
+	if err != nil {
+		return nil, response, err
+	}
+
+	serviceProfilesResponse := &ServiceProfilesResponse{}
+	json.Unmarshal(body, serviceProfilesResponse)
+
+	return serviceProfilesResponse.Results, response, nil
+}
+
+// AnalyzeProfileMigration compares every option of the body between the catalogs of its current and target service
+// profiles
+func AnalyzeProfileMigration(body *DeliveryServiceInstanceBody, source *ConfigOptionCatalog, target *ConfigOptionCatalog) *ProfileMigrationAnalysis {
+	analysis := &ProfileMigrationAnalysis{SourceProfile: source.ProfileName, TargetProfile: target.ProfileName}
+	for i, protocolSet := range body.ProtocolSets {
+		for j, option := range protocolSet.Options {
+			path := fmt.Sprintf("protocolSets[%d].options[%d]", i, j)
+			targetSchema, ok := target.Option(option.Name)
+			if !ok {
+				analysis.Issues = append(analysis.Issues, ProfileMigrationIssue{
+					Path:    path,
+					Option:  option.Name,
+					Kind:    MigrationIssueUnsupported,
+					Message: fmt.Sprintf("option %q is not supported by service profile %q", option.Name, target.ProfileName),
+				})
+				continue
+			}
+
+			sourceSchema, ok := source.Option(option.Name)
+			if ok && sameOptionSignature(sourceSchema.Details, targetSchema.Details) {
+				continue
+			}
+			coerced, err := target.CoerceOption(option)
+			analysis.Issues = append(analysis.Issues, ProfileMigrationIssue{
+				Path:      path,
+				Option:    option.Name,
+				Kind:      MigrationIssueSignatureChanged,
+				Message:   fmt.Sprintf("option %q has a different signature in service profile %q", option.Name, target.ProfileName),
+				Coercible: err == nil && len(target.validateOption(path, coerced)) == 0,
+			})
+		}
+	}
+	return analysis
+}
+
+// MigrateProfile returns a copy of the body moved to the target service profile, with its parameters coerced to the
+// target signatures. It fails without changing anything unless the migration is Safe.
+func MigrateProfile(body *DeliveryServiceInstanceBody, source *ConfigOptionCatalog, target *ConfigOptionCatalog) (*DeliveryServiceInstanceBody, *ProfileMigrationAnalysis, error) {
+	analysis := AnalyzeProfileMigration(body, source, target)
+	if !analysis.Safe() {
+		return nil, analysis, fmt.Errorf("cannot migrate from %s to %s automatically:\n%s", source.ProfileName, target.ProfileName, analysis)
+	}
+
+	migrated := CopyDeliveryServiceInstanceBody(body)
+	migrated.ServiceProfileName = target.ProfileName
+	if err := target.CoerceBody(migrated); err != nil {
+		return nil, analysis, err
+	}
+	return migrated, analysis, nil
+}
+
+// AnalyzeServiceProfileMigration fetches the catalogs of the body's service profile and of targetProfile and
+// analyzes moving the body between them
+func (c *ConfigurationClient) AnalyzeServiceProfileMigration(shortname string, body *DeliveryServiceInstanceBody, targetProfile string) (*ProfileMigrationAnalysis, error) {
+	source, target, err := c.migrationCatalogs(shortname, body.ServiceProfileName, targetProfile)
+	if err != nil {
+		return nil, err
+	}
+	return AnalyzeProfileMigration(body, source, target), nil
+}
+
+// MigrateServiceProfile fetches the catalogs of the body's service profile and of targetProfile and migrates the body
+// between them when that is safe
+func (c *ConfigurationClient) MigrateServiceProfile(shortname string, body *DeliveryServiceInstanceBody, targetProfile string) (*DeliveryServiceInstanceBody, *ProfileMigrationAnalysis, error) {
+	source, target, err := c.migrationCatalogs(shortname, body.ServiceProfileName, targetProfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return MigrateProfile(body, source, target)
+}
+
+func (c *ConfigurationClient) migrationCatalogs(shortname string, sourceProfile string, targetProfile string) (*ConfigOptionCatalog, *ConfigOptionCatalog, error) {
+	source, err := c.CachedConfigOptionCatalog(shortname, sourceProfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	target, err := c.CachedConfigOptionCatalog(shortname, targetProfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return source, target, nil
+}
+
+// sameOptionSignature compares the parts of two option schemas that decide which parameters are accepted
+func sameOptionSignature(a ConfigOptionDetails, b ConfigOptionDetails) bool {
+	if a.Repeatable != b.Repeatable || len(a.Arguments) != len(b.Arguments) {
+		return false
+	}
+	for i := range a.Arguments {
+		argumentA, argumentB := a.Arguments[i], b.Arguments[i]
+		if normalizeArgumentType(argumentA.Type) != normalizeArgumentType(argumentB.Type) ||
+			argumentA.Optional != argumentB.Optional ||
+			!reflect.DeepEqual(argumentA.Values, argumentB.Values) ||
+			!reflect.DeepEqual(argumentA.Min, argumentB.Min) ||
+			!reflect.DeepEqual(argumentA.Max, argumentB.Max) {
+			return false
+		}
+	}
+	return true
+}
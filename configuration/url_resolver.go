@@ -0,0 +1,153 @@
+package configuration
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URLMapping pairs a published URL with the source URL it is fetched from
+type URLMapping struct {
+	Instance     *DeliveryServiceInstance
+	ProtocolSet  *ProtocolSet
+	PublishedURL string
+	SourceURL    string
+}
+
+// URLResolver maps published URLs to source URLs, and back, using delivery service instances without calling the API
+type URLResolver struct {
+	instances []DeliveryServiceInstance
+	bodies    []*DeliveryServiceInstanceBody
+}
+
+// NewURLResolver builds a resolver over the said instances
+func NewURLResolver(instances ...DeliveryServiceInstance) *URLResolver {
+	resolver := &URLResolver{instances: instances}
+	for i := range instances {
+		resolver.bodies = append(resolver.bodies, Normalize(&instances[i].Body))
+	}
+	return resolver
+}
+
+// Resolve finds the instance publishing the said URL, picking the longest matching PublishedURLPath, and computes
+// the source URL it is fetched from
+func (r *URLResolver) Resolve(publishedURL string) (*URLMapping, error) {
+	parsedURL, err := url.Parse(publishedURL)
+	if err != nil {
+		return nil, err
+	}
+	protocol := strings.ToUpper(parsedURL.Scheme)
+	hostname := strings.ToLower(parsedURL.Hostname())
+
+	var best *URLMapping
+	bestLength := -1
+	for i, body := range r.bodies {
+		if body.PublishedHostname != hostname || !hasPathPrefix(parsedURL.EscapedPath(), body.PublishedURLPath) {
+			continue
+		}
+		if len(body.PublishedURLPath) <= bestLength {
+			continue
+		}
+		for j := range body.ProtocolSets {
+			protocolSet := &body.ProtocolSets[j]
+			if protocolSet.PublishedProtocol != protocol {
+				continue
+			}
+			remainder := strings.TrimPrefix(parsedURL.EscapedPath(), body.PublishedURLPath)
+			best = &URLMapping{
+				Instance:     &r.instances[i],
+				ProtocolSet:  protocolSet,
+				PublishedURL: publishedURL,
+				SourceURL:    buildURL(protocolSet.SourceProtocol, body.SourceHostname, protocolSet.SourcePort, joinURLPath(body.SourceURLPath, remainder), parsedURL.RawQuery),
+			}
+			bestLength = len(body.PublishedURLPath)
+			break
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no delivery service instance publishes %s", publishedURL)
+	}
+	return best, nil
+}
+
+// ReverseResolve finds every published URL that is fetched from the said source URL
+func (r *URLResolver) ReverseResolve(sourceURL string) ([]URLMapping, error) {
+	parsedURL, err := url.Parse(sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	protocol := strings.ToUpper(parsedURL.Scheme)
+	hostname := strings.ToLower(parsedURL.Hostname())
+	port := effectivePort(protocol, parsedURL.Port())
+
+	var mappings []URLMapping
+	for i, body := range r.bodies {
+		if body.SourceHostname != hostname || !hasPathPrefix(parsedURL.EscapedPath(), body.SourceURLPath) {
+			continue
+		}
+		for j := range body.ProtocolSets {
+			protocolSet := &body.ProtocolSets[j]
+			if protocolSet.SourceProtocol != protocol || protocolSet.SourcePort == nil || *protocolSet.SourcePort != port {
+				continue
+			}
+			remainder := strings.TrimPrefix(parsedURL.EscapedPath(), body.SourceURLPath)
+			mappings = append(mappings, URLMapping{
+				Instance:     &r.instances[i],
+				ProtocolSet:  protocolSet,
+				PublishedURL: buildURL(protocolSet.PublishedProtocol, body.PublishedHostname, nil, joinURLPath(body.PublishedURLPath, remainder), parsedURL.RawQuery),
+				SourceURL:    sourceURL,
+			})
+		}
+	}
+
+	if len(mappings) == 0 {
+		return nil, fmt.Errorf("no delivery service instance fetches from %s", sourceURL)
+	}
+	return mappings, nil
+}
+
+// hasPathPrefix reports whether path is within prefix, treating prefix as a whole path segment unless it ends in "/"
+func hasPathPrefix(path string, prefix string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+func joinURLPath(base string, remainder string) string {
+	switch {
+	case remainder == "":
+		return base
+	case strings.HasSuffix(base, "/") && strings.HasPrefix(remainder, "/"):
+		return base + remainder[1:]
+	case !strings.HasSuffix(base, "/") && !strings.HasPrefix(remainder, "/"):
+		return base + "/" + remainder
+	}
+	return base + remainder
+}
+
+func effectivePort(protocol string, port string) int {
+	if port == "" {
+		return defaultSourcePorts[protocol]
+	}
+	number, _ := strconv.Atoi(port)
+	return number
+}
+
+func buildURL(protocol string, hostname string, port *int, path string, rawQuery string) string {
+	host := hostname
+	if port != nil && *port != defaultSourcePorts[protocol] {
+		host = net.JoinHostPort(hostname, strconv.Itoa(*port))
+	}
+	builtURL := strings.ToLower(protocol) + "://" + host + path
+	if rawQuery != "" {
+		builtURL += "?" + rawQuery
+	}
+	return builtURL
+}
@@ -0,0 +1,99 @@
+package configuration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testResolver() *URLResolver {
+	return NewURLResolver(
+		DeliveryServiceInstance{UUID: "site", Body: DeliveryServiceInstanceBody{
+			PublishedHostname: "www.example.com",
+			PublishedURLPath:  "/",
+			SourceHostname:    "origin.example.com",
+			SourceURLPath:     "/site",
+			ProtocolSets: []ProtocolSet{
+				{PublishedProtocol: "HTTP", SourceProtocol: "HTTP"},
+				{PublishedProtocol: "HTTPS", SourceProtocol: "HTTPS", SourcePort: intPointer(8443)},
+			},
+		}},
+		DeliveryServiceInstance{UUID: "video", Body: DeliveryServiceInstanceBody{
+			PublishedHostname: "www.example.com",
+			PublishedURLPath:  "/video",
+			SourceHostname:    "media.example.com",
+			SourceURLPath:     "/",
+			ProtocolSets: []ProtocolSet{
+				{PublishedProtocol: "HTTPS", SourceProtocol: "HTTPS"},
+			},
+		}},
+	)
+}
+
+func TestURLResolverResolve(t *testing.T) {
+	tests := []struct {
+		publishedURL string
+		wantUUID     string
+		wantSource   string
+	}{
+		{publishedURL: "https://www.example.com/page?x=1", wantUUID: "site", wantSource: "https://origin.example.com:8443/site/page?x=1"},
+		{publishedURL: "http://www.example.com/page", wantUUID: "site", wantSource: "http://origin.example.com/site/page"},
+		{publishedURL: "https://WWW.EXAMPLE.COM/", wantUUID: "site", wantSource: "https://origin.example.com:8443/site"},
+		{publishedURL: "https://www.example.com/video/clip.mp4", wantUUID: "video", wantSource: "https://media.example.com/clip.mp4"},
+		{publishedURL: "https://www.example.com/videos/clip.mp4", wantUUID: "site", wantSource: "https://origin.example.com:8443/site/videos/clip.mp4"},
+		{publishedURL: "http://www.example.com/video/clip.mp4", wantUUID: "site", wantSource: "http://origin.example.com/site/video/clip.mp4"},
+		{publishedURL: "https://other.example.com/page"},
+	}
+	resolver := testResolver()
+	for _, test := range tests {
+		t.Run(test.publishedURL, func(t *testing.T) {
+			mapping, err := resolver.Resolve(test.publishedURL)
+			if test.wantUUID == "" {
+				if err == nil {
+					t.Errorf("Resolve() = %+v, want an error", mapping)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mapping.Instance.UUID != test.wantUUID || mapping.SourceURL != test.wantSource {
+				t.Errorf("Resolve() = %s via %s, want %s via %s", mapping.SourceURL, mapping.Instance.UUID, test.wantSource, test.wantUUID)
+			}
+		})
+	}
+}
+
+func TestURLResolverReverseResolve(t *testing.T) {
+	tests := []struct {
+		sourceURL string
+		want      []string
+	}{
+		{sourceURL: "https://media.example.com/clip.mp4", want: []string{"https://www.example.com/video/clip.mp4"}},
+		{sourceURL: "http://origin.example.com/site/a?x=1", want: []string{"http://www.example.com/a?x=1"}},
+		{sourceURL: "https://origin.example.com:8443/site/a", want: []string{"https://www.example.com/a"}},
+		{sourceURL: "https://origin.example.com/site/a"},
+		{sourceURL: "http://origin.example.com/other/a"},
+	}
+	resolver := testResolver()
+	for _, test := range tests {
+		t.Run(test.sourceURL, func(t *testing.T) {
+			mappings, err := resolver.ReverseResolve(test.sourceURL)
+			if test.want == nil {
+				if err == nil {
+					t.Errorf("ReverseResolve() = %+v, want an error", mappings)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, mapping := range mappings {
+				got = append(got, mapping.PublishedURL)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ReverseResolve() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -21,6 +21,14 @@ type EdgeFunction struct {
 	Version              int                   `json:"version,omitempty"`
 }
 
+type EdgeFunctionsResponse struct {
+	Functions []EdgeFunction `json:"functions"`
+}
+
+type EdgeFunctionAliasesResponse struct {
+	Aliases []EdgeFunctionAlias `json:"aliases"`
+}
+
 type ReservedConcurrency struct {
 	ReservedConcurrency int `json:"reservedConcurrency"`
 }
@@ -51,6 +59,19 @@ func (c *EdgeFunctionsClient) GetEdgeFunction(name string, shortname string) (*E
 	return edgeFunctionResponse, response, nil
 }
 
+func (c *EdgeFunctionsClient) ListEdgeFunctions(shortname string) ([]EdgeFunction, *http.Response, error) {
+	body, response, err := c.Auth.HTTPGet(c.BaseUrl + "/" + shortname + "/functions")
+	if err != nil {
+		return nil, response, err
+	}
+
+	edgeFunctionsResponse := &EdgeFunctionsResponse{}
+	if err = json.Unmarshal(body, edgeFunctionsResponse); err != nil {
+		return nil, response, err
+	}
+	return edgeFunctionsResponse.Functions, response, nil
+}
+
 func (c *EdgeFunctionsClient) CreateEdgeFunction(shortname string, edgeFunction *EdgeFunction) (*EdgeFunction, *http.Response, error) {
 	jsonRequest, _ := json.Marshal(edgeFunction)
 
@@ -155,6 +176,19 @@ func (c *EdgeFunctionsClient) UpdateEdgeFunctionAlias(fnName, shortname, aliasNa
 	return aliasResponse, response, nil
 }
 
+func (c *EdgeFunctionsClient) ListEdgeFunctionAliases(fnName, shortname string) ([]EdgeFunctionAlias, *http.Response, error) {
+	body, response, err := c.Auth.HTTPGet(c.BaseUrl + "/" + shortname + "/functions/" + fnName + "/aliases")
+	if err != nil {
+		return nil, response, err
+	}
+
+	aliasesResponse := &EdgeFunctionAliasesResponse{}
+	if err = json.Unmarshal(body, aliasesResponse); err != nil {
+		return nil, response, err
+	}
+	return aliasesResponse.Aliases, response, nil
+}
+
 func (c *EdgeFunctionsClient) GetEdgeFunctionAlias(fnName, shortname, aliasName string) (*EdgeFunctionAlias, *http.Response, error) {
 
 	body, response, err := c.Auth.HTTPGet(c.BaseUrl + "/" + shortname + "/functions/" + fnName + "/aliases/" + aliasName)
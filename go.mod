@@ -1,3 +1,5 @@
 module github.com/llnw/llnw-sdk-go
 
 go 1.13
+
+require gopkg.in/yaml.v2 v2.4.0
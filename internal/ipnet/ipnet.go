@@ -0,0 +1,24 @@
+// Package ipnet parses the IP address restrictions shared by MediaVault URLs, IP/geo match rules and IP allow lists.
+package ipnet
+
+import (
+	"net"
+	"strings"
+)
+
+// ParseNetwork parses a CIDR range, or a single IPv4 or IPv6 address as a network holding only that address
+func ParseNetwork(restriction string) (*net.IPNet, error) {
+	if strings.Contains(restriction, "/") {
+		_, network, err := net.ParseCIDR(restriction)
+		return network, err
+	}
+	ip := net.ParseIP(restriction)
+	if ip == nil {
+		return nil, &net.ParseError{Type: "IP address", Text: restriction}
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
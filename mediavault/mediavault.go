@@ -0,0 +1,227 @@
+// Package mediavault signs and verifies MediaVault protected URLs without calling any API.
+//
+// A signed URL carries its restrictions as query parameters, followed by a hash over the URL up to that point:
+//
+//	s   start of the validity window, in seconds since the Unix epoch
+//	e   end of the validity window, in seconds since the Unix epoch
+//	ip  the client IP address or CIDR range allowed to use the URL
+//	h   hex encoded MD5 of the secret followed by every character of the URL before "&h=" (or "?h=")
+//
+// Test vectors, all signed with the secret "secret". Each hash was computed outside this package, with
+// printf '%s' 'secret<URL before "&h=">' | md5sum, from the construction above:
+//
+//	http://example.com/video.mp4 expiring at 1600000000
+//	http://example.com/video.mp4?e=1600000000&h=140c87728eec15343ea53cdf974b2d48
+//
+//	https://example.com/live/playlist.m3u8?token=abc valid from 1600000000 to 1600003600 for 192.0.2.0/24
+//	https://example.com/live/playlist.m3u8?token=abc&s=1600000000&e=1600003600&ip=192.0.2.0%2F24&h=28ca23ed383884897de790c8fe9e6102
+package mediavault
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/llnw/llnw-sdk-go/internal/ipnet"
+)
+
+const (
+	ParamStart = "s"
+	ParamEnd   = "e"
+	ParamIP    = "ip"
+	ParamHash  = "h"
+)
+
+var (
+	ErrMissingHash   = errors.New("mediavault: URL is not signed")
+	ErrInvalidHash   = errors.New("mediavault: URL signature does not match")
+	ErrNotYetValid   = errors.New("mediavault: URL is not valid yet")
+	ErrExpired       = errors.New("mediavault: URL has expired")
+	ErrIPNotAllowed  = errors.New("mediavault: client IP is not allowed to use URL")
+	ErrInvalidParams = errors.New("mediavault: URL has malformed MediaVault parameters")
+	ErrAlreadySigned = errors.New("mediavault: URL already has MediaVault parameters")
+)
+
+// restrictionParams are the parameters Sign adds before the hash
+var restrictionParams = []string{ParamStart, ParamEnd, ParamIP}
+
+// Options restricts a signed URL. Zero fields add no restriction. IP is a single address or a CIDR range.
+type Options struct {
+	Start  time.Time
+	Expiry time.Time
+	IP     string
+}
+
+// Sign adds the restrictions in options to rawURL and signs it with secret. Any fragment is dropped. URLs that already
+// carry any of the s, e, ip or h parameters are rejected with ErrAlreadySigned: the verifier would read the restriction
+// that comes first, which would not be the signed one.
+func Sign(rawURL string, secret string, options Options) (string, error) {
+	if options.IP != "" {
+		if _, err := ipnet.ParseNetwork(options.IP); err != nil {
+			return "", err
+		}
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := parsedURL.Query()
+	for _, param := range append(restrictionParams, ParamHash) {
+		if _, ok := query[param]; ok {
+			return "", ErrAlreadySigned
+		}
+	}
+
+	signed := rawURL
+	if i := strings.IndexByte(signed, '#'); i >= 0 {
+		signed = signed[:i]
+	}
+	var params []string
+	if !options.Start.IsZero() {
+		params = append(params, ParamStart+"="+strconv.FormatInt(options.Start.Unix(), 10))
+	}
+	if !options.Expiry.IsZero() {
+		params = append(params, ParamEnd+"="+strconv.FormatInt(options.Expiry.Unix(), 10))
+	}
+	if options.IP != "" {
+		params = append(params, ParamIP+"="+url.QueryEscape(options.IP))
+	}
+	if len(params) > 0 {
+		signed = appendQuery(signed, strings.Join(params, "&"))
+	}
+
+	return appendQuery(signed, ParamHash+"="+hash(secret, signed)), nil
+}
+
+// Verifier checks signed URLs against a secret, and against previous secrets during their grace window
+type Verifier struct {
+	secret   string
+	previous []previousSecret
+	// Now returns the current time and can be replaced to verify URLs at another time
+	Now func() time.Time
+}
+
+type previousSecret struct {
+	secret string
+	until  time.Time
+}
+
+// NewVerifier returns a Verifier for URLs signed with secret
+func NewVerifier(secret string) *Verifier {
+	return &Verifier{secret: secret, Now: time.Now}
+}
+
+// AcceptPreviousSecret also accepts URLs signed with secret until the said time, so that URLs handed out before a
+// secret was rotated keep working for a while
+func (v *Verifier) AcceptPreviousSecret(secret string, until time.Time) *Verifier {
+	v.previous = append(v.previous, previousSecret{secret: secret, until: until})
+	return v
+}
+
+// Verify checks the signature of signedURL and its time and IP restrictions. clientIP may be nil to skip the IP
+// check. URLs repeating a restriction, or with another h parameter before the hash, are rejected with
+// ErrInvalidParams.
+func (v *Verifier) Verify(signedURL string, clientIP net.IP) error {
+	signed, signature, err := splitSignature(signedURL)
+	if err != nil {
+		return err
+	}
+	now := v.Now()
+	if !v.signatureMatches(signed, signature, now) {
+		return ErrInvalidHash
+	}
+	return checkRestrictions(signed, clientIP, now)
+}
+
+func (v *Verifier) signatureMatches(signed string, signature string, now time.Time) bool {
+	if hashMatches(v.secret, signed, signature) {
+		return true
+	}
+	for _, previous := range v.previous {
+		if now.Before(previous.until) && hashMatches(previous.secret, signed, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitSignature(signedURL string) (string, string, error) {
+	if i := strings.IndexByte(signedURL, '#'); i >= 0 {
+		signedURL = signedURL[:i]
+	}
+	i := strings.LastIndex(signedURL, "&"+ParamHash+"=")
+	if i < 0 {
+		i = strings.LastIndex(signedURL, "?"+ParamHash+"=")
+	}
+	if i < 0 {
+		return "", "", ErrMissingHash
+	}
+	return signedURL[:i], signedURL[i+len(ParamHash)+2:], nil
+}
+
+func checkRestrictions(signed string, clientIP net.IP, now time.Time) error {
+	parsedURL, err := url.Parse(signed)
+	if err != nil {
+		return ErrInvalidParams
+	}
+	query := parsedURL.Query()
+	if _, ok := query[ParamHash]; ok {
+		return ErrInvalidParams
+	}
+	for _, param := range restrictionParams {
+		if len(query[param]) > 1 {
+			return ErrInvalidParams
+		}
+	}
+
+	if start := query.Get(ParamStart); start != "" {
+		seconds, err := strconv.ParseInt(start, 10, 64)
+		if err != nil {
+			return ErrInvalidParams
+		}
+		if now.Before(time.Unix(seconds, 0)) {
+			return ErrNotYetValid
+		}
+	}
+	if end := query.Get(ParamEnd); end != "" {
+		seconds, err := strconv.ParseInt(end, 10, 64)
+		if err != nil {
+			return ErrInvalidParams
+		}
+		if !now.Before(time.Unix(seconds, 0)) {
+			return ErrExpired
+		}
+	}
+	if ip := query.Get(ParamIP); ip != "" && clientIP != nil {
+		network, err := ipnet.ParseNetwork(ip)
+		if err != nil {
+			return ErrInvalidParams
+		}
+		if !network.Contains(clientIP) {
+			return ErrIPNotAllowed
+		}
+	}
+	return nil
+}
+
+func appendQuery(rawURL string, query string) string {
+	if strings.Contains(rawURL, "?") {
+		return rawURL + "&" + query
+	}
+	return rawURL + "?" + query
+}
+
+func hash(secret string, signed string) string {
+	sum := md5.Sum([]byte(secret + signed))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashMatches(secret string, signed string, signature string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash(secret, signed)), []byte(strings.ToLower(signature))) == 1
+}
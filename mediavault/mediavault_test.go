@@ -0,0 +1,124 @@
+package mediavault
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The vectors are those of the package documentation, whose hashes were computed with md5sum rather than by Sign
+func TestSignVectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		options Options
+		want    string
+	}{
+		{
+			name:    "expiry only",
+			rawURL:  "http://example.com/video.mp4",
+			options: Options{Expiry: time.Unix(1600000000, 0)},
+			want:    "http://example.com/video.mp4?e=1600000000&h=140c87728eec15343ea53cdf974b2d48",
+		},
+		{
+			name:    "window and IP range on a URL with a query",
+			rawURL:  "https://example.com/live/playlist.m3u8?token=abc",
+			options: Options{Start: time.Unix(1600000000, 0), Expiry: time.Unix(1600003600, 0), IP: "192.0.2.0/24"},
+			want:    "https://example.com/live/playlist.m3u8?token=abc&s=1600000000&e=1600003600&ip=192.0.2.0%2F24&h=28ca23ed383884897de790c8fe9e6102",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Sign(test.rawURL, "secret", test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("Sign() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSignRejectsInvalidIP(t *testing.T) {
+	if _, err := Sign("http://example.com/video.mp4", "secret", Options{IP: "not-an-ip"}); err == nil {
+		t.Error("Sign() with an invalid IP succeeded")
+	}
+}
+
+func TestSignRejectsSignedURLs(t *testing.T) {
+	for _, rawURL := range []string{
+		"http://example.com/v.mp4?e=9999999999",
+		"http://example.com/v.mp4?token=abc&ip=0.0.0.0%2F0",
+		"http://example.com/v.mp4?s=0",
+		"http://example.com/v.mp4?h=140c87728eec15343ea53cdf974b2d48",
+	} {
+		if signed, err := Sign(rawURL, "secret", Options{Expiry: time.Unix(1600000000, 0), IP: "192.0.2.1"}); err != ErrAlreadySigned {
+			t.Errorf("Sign(%s) = %s, %v, want %v", rawURL, signed, err, ErrAlreadySigned)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	start, expiry := time.Unix(1600000000, 0), time.Unix(1600003600, 0)
+	signed, err := Sign("https://example.com/live/playlist.m3u8?token=abc", "secret", Options{Start: start, Expiry: expiry, IP: "192.0.2.0/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// URLs signed as an older Sign would have signed them when given URLs that already carried restrictions
+	withHash := func(unsigned string) string {
+		return unsigned + "&h=" + hash("secret", unsigned)
+	}
+	repeatedRestrictions := withHash("http://example.com/v.mp4?e=9999999999&ip=0.0.0.0%2F0&e=1600000000&ip=192.0.2.1")
+	repeatedHash := withHash("http://example.com/v.mp4?h=0&e=1600003600")
+
+	tests := []struct {
+		name      string
+		secret    string
+		signedURL string
+		clientIP  net.IP
+		now       time.Time
+		want      error
+	}{
+		{name: "valid", secret: "secret", signedURL: signed, clientIP: net.ParseIP("192.0.2.10"), now: start.Add(time.Minute)},
+		{name: "IP check skipped", secret: "secret", signedURL: signed, now: start},
+		{name: "wrong secret", secret: "other", signedURL: signed, now: start, want: ErrInvalidHash},
+		{name: "tampered path", secret: "secret", signedURL: strings.Replace(signed, "playlist", "other", 1), now: start, want: ErrInvalidHash},
+		{name: "tampered expiry", secret: "secret", signedURL: strings.Replace(signed, "e=1600003600", "e=1700000000", 1), now: start, want: ErrInvalidHash},
+		{name: "unsigned", secret: "secret", signedURL: "https://example.com/live/playlist.m3u8?token=abc", now: start, want: ErrMissingHash},
+		{name: "not yet valid", secret: "secret", signedURL: signed, now: start.Add(-time.Second), want: ErrNotYetValid},
+		{name: "expired", secret: "secret", signedURL: signed, now: expiry, want: ErrExpired},
+		{name: "IP outside range", secret: "secret", signedURL: signed, clientIP: net.ParseIP("198.51.100.1"), now: start, want: ErrIPNotAllowed},
+		{name: "repeated restrictions", secret: "secret", signedURL: repeatedRestrictions, clientIP: net.ParseIP("198.51.100.1"), now: time.Unix(1700000000, 0), want: ErrInvalidParams},
+		{name: "hash before the restrictions", secret: "secret", signedURL: repeatedHash, now: start, want: ErrInvalidParams},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier := NewVerifier(test.secret)
+			verifier.Now = func() time.Time { return test.now }
+			if err := verifier.Verify(test.signedURL, test.clientIP); err != test.want {
+				t.Errorf("Verify() = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestVerifyPreviousSecret(t *testing.T) {
+	signed, err := Sign("http://example.com/video.mp4", "old", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotatedAt := time.Unix(1600000000, 0)
+	verifier := NewVerifier("new").AcceptPreviousSecret("old", rotatedAt.Add(time.Hour))
+
+	verifier.Now = func() time.Time { return rotatedAt.Add(time.Minute) }
+	if err := verifier.Verify(signed, nil); err != nil {
+		t.Errorf("Verify() within the grace window = %v, want nil", err)
+	}
+	verifier.Now = func() time.Time { return rotatedAt.Add(time.Hour) }
+	if err := verifier.Verify(signed, nil); err != ErrInvalidHash {
+		t.Errorf("Verify() after the grace window = %v, want %v", err, ErrInvalidHash)
+	}
+}
@@ -0,0 +1,357 @@
+// Package promote copies delivery service instances, edge functions and edge function aliases from one shortname to
+// another, rewriting hostnames, URL paths and environment variable values on the way. Changes are planned first so
+// that they can be reviewed before they are applied.
+package promote
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/llnw/llnw-sdk-go/configuration"
+	"github.com/llnw/llnw-sdk-go/edgefunctions"
+)
+
+const (
+	TargetPublishedHostname   = "publishedHostname"
+	TargetSourceHostname      = "sourceHostname"
+	TargetURLPath             = "urlPath"
+	TargetEnvironmentVariable = "environmentVariable"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+)
+
+// RewriteRule replaces every occurrence of Old with New in the fields named by Target. TargetURLPath applies to both
+// the published and source URL paths. For TargetEnvironmentVariable, Name restricts the rule to a single variable.
+// Old must not be empty.
+type RewriteRule struct {
+	Target string
+	Name   string
+	Old    string
+	New    string
+}
+
+// Selection picks what is promoted. A nil DeliveryServiceInstances filter selects every instance; aliases are
+// promoted with the edge functions they belong to.
+type Selection struct {
+	DeliveryServiceInstances configuration.DeliveryServiceInstanceFilter
+	EdgeFunctions            []string
+}
+
+// EdgeFunctionChange creates or updates an edge function in the target shortname
+type EdgeFunctionChange struct {
+	Action   string
+	Function *edgefunctions.EdgeFunction
+	Archive  []byte
+}
+
+// AliasChange creates or updates an edge function alias in the target shortname. Version numbers are per shortname,
+// so when FollowsFunction is set the alias's FunctionVersion is only known once the plan is applied: it becomes the
+// version the promoted edge function gets in the target.
+type AliasChange struct {
+	Action          string
+	Function        string
+	Alias           *edgefunctions.EdgeFunctionAlias
+	FollowsFunction bool
+}
+
+// RefusedAlias is an alias that cannot be promoted because it points at a version of its edge function other than
+// the one promoted, which has no counterpart in the target shortname
+type RefusedAlias struct {
+	Function        string
+	Alias           string
+	FunctionVersion string
+}
+
+// Plan lists the changes a promotion makes to the target shortname
+type Plan struct {
+	Source                   string
+	Target                   string
+	EdgeFunctions            []EdgeFunctionChange
+	Aliases                  []AliasChange
+	RefusedAliases           []RefusedAlias
+	DeliveryServiceInstances *configuration.Plan
+}
+
+func (p *Plan) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Promote %s to %s\n", p.Source, p.Target)
+	for _, change := range p.EdgeFunctions {
+		fmt.Fprintf(&builder, "%s edge function %s\n", change.Action, change.Function.Name)
+	}
+	for _, change := range p.Aliases {
+		fmt.Fprintf(&builder, "%s edge function alias %s:%s\n", change.Action, change.Function, change.Alias.Name)
+	}
+	for _, refused := range p.RefusedAliases {
+		fmt.Fprintf(&builder, "refuse edge function alias %s:%s pointing at unpromoted version %s\n", refused.Function, refused.Alias, refused.FunctionVersion)
+	}
+	builder.WriteString(p.DeliveryServiceInstances.String())
+	return builder.String()
+}
+
+// NewPlan reads the selected resources from the source shortname, applies the rewrite rules and compares the result
+// with the target shortname. Nothing is changed until the plan is applied.
+func NewPlan(configurationClient *configuration.ConfigurationClient, edgeFunctionsClient *edgefunctions.EdgeFunctionsClient, source string, target string, selection Selection, rules []RewriteRule) (*Plan, error) {
+	if err := ValidateRewriteRules(rules); err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Source: source, Target: target}
+	if err := planEdgeFunctions(edgeFunctionsClient, plan, selection.EdgeFunctions, rules); err != nil {
+		return nil, err
+	}
+
+	instances, _, err := configurationClient.ListDeliveryServiceInstances(source)
+	if err != nil {
+		return nil, fmt.Errorf("listing delivery service instances of %s: %s", source, err)
+	}
+	var desired []configuration.DeliveryServiceInstanceBody
+	for i := range instances {
+		if selection.DeliveryServiceInstances != nil && !selection.DeliveryServiceInstances(&instances[i]) {
+			continue
+		}
+		desired = append(desired, RewriteDeliveryServiceInstanceBody(&instances[i].Body, rules))
+	}
+	if plan.DeliveryServiceInstances, err = configurationClient.PlanDeliveryServiceInstances(target, desired, false); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// ValidateRewriteRules checks that every rule has a known target and something to replace. An empty Old would
+// insert New between every character of the rewritten values.
+func ValidateRewriteRules(rules []RewriteRule) error {
+	for i, rule := range rules {
+		switch rule.Target {
+		case TargetPublishedHostname, TargetSourceHostname, TargetURLPath, TargetEnvironmentVariable:
+		default:
+			return fmt.Errorf("rewrite rule %d: unknown rewrite target %q", i, rule.Target)
+		}
+		if rule.Old == "" {
+			return fmt.Errorf("rewrite rule %d: nothing to replace in %s", i, rule.Target)
+		}
+	}
+	return nil
+}
+
+func planEdgeFunctions(edgeFunctionsClient *edgefunctions.EdgeFunctionsClient, plan *Plan, names []string, rules []RewriteRule) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	targetFunctions, _, err := edgeFunctionsClient.ListEdgeFunctions(plan.Target)
+	if err != nil {
+		return fmt.Errorf("listing edge functions of %s: %s", plan.Target, err)
+	}
+	targetExists := map[string]bool{}
+	for _, targetFunction := range targetFunctions {
+		targetExists[targetFunction.Name] = true
+	}
+
+	for _, name := range names {
+		sourceFunction, _, err := edgeFunctionsClient.GetEdgeFunction(name, plan.Source)
+		if err != nil {
+			return fmt.Errorf("fetching edge function %s from %s: %s", name, plan.Source, err)
+		}
+		archive := sourceFunction.FunctionArchive
+		desired := RewriteEdgeFunction(sourceFunction, rules)
+
+		change := EdgeFunctionChange{Action: ActionCreate, Function: desired, Archive: archive}
+		targetAliases := map[string]edgefunctions.EdgeFunctionAlias{}
+		var targetFunction *edgefunctions.EdgeFunction
+		if targetExists[name] {
+			targetFunction, _, err = edgeFunctionsClient.GetEdgeFunction(name, plan.Target)
+			if err != nil {
+				return fmt.Errorf("fetching edge function %s from %s: %s", name, plan.Target, err)
+			}
+			change.Action = ActionUpdate
+			if edgeFunctionsEqual(desired, targetFunction) && sourceFunction.Sha256 == targetFunction.Sha256 {
+				change.Action = ""
+			}
+
+			aliases, _, err := edgeFunctionsClient.ListEdgeFunctionAliases(name, plan.Target)
+			if err != nil {
+				return fmt.Errorf("listing aliases of edge function %s in %s: %s", name, plan.Target, err)
+			}
+			for _, alias := range aliases {
+				targetAliases[alias.Name] = alias
+			}
+		}
+		if change.Action != "" {
+			plan.EdgeFunctions = append(plan.EdgeFunctions, change)
+		}
+
+		aliases, _, err := edgeFunctionsClient.ListEdgeFunctionAliases(name, plan.Source)
+		if err != nil {
+			return fmt.Errorf("listing aliases of edge function %s in %s: %s", name, plan.Source, err)
+		}
+		for i := range aliases {
+			alias := aliases[i]
+			alias.RevisionID = 0
+			aliasChange := AliasChange{Action: ActionCreate, Function: name, Alias: &alias}
+			if _, err := strconv.Atoi(alias.FunctionVersion); err == nil {
+				if alias.FunctionVersion != strconv.Itoa(sourceFunction.Version) {
+					plan.RefusedAliases = append(plan.RefusedAliases, RefusedAlias{Function: name, Alias: alias.Name, FunctionVersion: alias.FunctionVersion})
+					continue
+				}
+				if change.Action == "" {
+					// the target already runs the promoted code
+					alias.FunctionVersion = strconv.Itoa(targetFunction.Version)
+				} else {
+					alias.FunctionVersion, aliasChange.FollowsFunction = "", true
+				}
+			}
+
+			targetAlias, exists := targetAliases[alias.Name]
+			if exists {
+				if !aliasChange.FollowsFunction && targetAlias.Description == alias.Description && targetAlias.FunctionVersion == alias.FunctionVersion {
+					continue
+				}
+				aliasChange.Action = ActionUpdate
+			}
+			plan.Aliases = append(plan.Aliases, aliasChange)
+		}
+	}
+	return nil
+}
+
+// edgeFunctionsEqual compares the configuration of two edge functions, ignoring their code and server-only fields
+func edgeFunctionsEqual(a *edgefunctions.EdgeFunction, b *edgefunctions.EdgeFunction) bool {
+	return a.Description == b.Description &&
+		a.Handler == b.Handler &&
+		a.Runtime == b.Runtime &&
+		a.Memory == b.Memory &&
+		a.Timeout == b.Timeout &&
+		a.CanDebug == b.CanDebug &&
+		a.ReservedConcurrency == b.ReservedConcurrency &&
+		reflect.DeepEqual(a.EnvironmentVariables, b.EnvironmentVariables)
+}
+
+// RewriteDeliveryServiceInstanceBody returns a deep copy of the body with the hostname and URL path rules applied.
+// Rules with an empty Old are ignored.
+func RewriteDeliveryServiceInstanceBody(body *configuration.DeliveryServiceInstanceBody, rules []RewriteRule) configuration.DeliveryServiceInstanceBody {
+	rewritten := configuration.CopyDeliveryServiceInstanceBody(body)
+	for _, rule := range rules {
+		switch rule.Target {
+		case TargetPublishedHostname:
+			rewritten.PublishedHostname = rule.apply(rewritten.PublishedHostname)
+		case TargetSourceHostname:
+			rewritten.SourceHostname = rule.apply(rewritten.SourceHostname)
+		case TargetURLPath:
+			rewritten.PublishedURLPath = rule.apply(rewritten.PublishedURLPath)
+			rewritten.SourceURLPath = rule.apply(rewritten.SourceURLPath)
+		}
+	}
+	return *rewritten
+}
+
+// RewriteEdgeFunction returns a copy of the edge function configuration, without its archive or server-only fields,
+// with the environment variable rules applied. Rules with an empty Old are ignored.
+func RewriteEdgeFunction(edgeFunction *edgefunctions.EdgeFunction, rules []RewriteRule) *edgefunctions.EdgeFunction {
+	rewritten := *edgeFunction
+	rewritten.FunctionArchive = nil
+	rewritten.Sha256, rewritten.RevisionID, rewritten.Version = "", 0, 0
+	rewritten.EnvironmentVariables = append([]edgefunctions.EnvironmentVariable(nil), edgeFunction.EnvironmentVariables...)
+	for _, rule := range rules {
+		if rule.Target != TargetEnvironmentVariable {
+			continue
+		}
+		for i, variable := range rewritten.EnvironmentVariables {
+			if rule.Name == "" || rule.Name == variable.Name {
+				rewritten.EnvironmentVariables[i].Value = rule.apply(variable.Value)
+			}
+		}
+	}
+	return &rewritten
+}
+
+func (r RewriteRule) apply(value string) string {
+	if r.Old == "" {
+		return value
+	}
+	return strings.Replace(value, r.Old, r.New, -1)
+}
+
+// Result is the outcome of a single change of an applied Plan
+type Result struct {
+	Resource string
+	Name     string
+	Action   string
+	Err      error
+}
+
+// Report holds the result of every change of an applied Plan
+type Report struct {
+	Results                  []Result
+	DeliveryServiceInstances *configuration.ApplyReport
+}
+
+// Err summarises the failed changes, or returns nil if every change was applied
+func (r *Report) Err() error {
+	var messages []string
+	for _, result := range r.Results {
+		if result.Err != nil {
+			messages = append(messages, fmt.Sprintf("%s %s %s: %s", result.Action, result.Resource, result.Name, result.Err))
+		}
+	}
+	if err := r.DeliveryServiceInstances.Err(); err != nil {
+		messages = append(messages, err.Error())
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return fmt.Errorf("promotion failed: %s", strings.Join(messages, "; "))
+}
+
+// Apply makes the changes of the plan in the target shortname: edge functions first, then aliases and finally
+// delivery service instances. Aliases following their edge function point at the version it got in the target.
+// Failed changes do not stop later changes and are reported in the returned Report. Refused aliases are left alone.
+func Apply(configurationClient *configuration.ConfigurationClient, edgeFunctionsClient *edgefunctions.EdgeFunctionsClient, plan *Plan) *Report {
+	report := &Report{}
+	targetVersions := map[string]int{}
+	for _, change := range plan.EdgeFunctions {
+		result := Result{Resource: "edge function", Name: change.Function.Name, Action: change.Action}
+		var promoted *edgefunctions.EdgeFunction
+		if change.Action == ActionCreate {
+			withArchive := *change.Function
+			withArchive.FunctionArchive = change.Archive
+			promoted, _, result.Err = edgeFunctionsClient.CreateEdgeFunction(plan.Target, &withArchive)
+		} else {
+			promoted, _, result.Err = edgeFunctionsClient.UpdateEdgeFunctionConfiguration(change.Function.Name, plan.Target, change.Function)
+			if result.Err == nil && len(change.Archive) > 0 {
+				promoted, _, result.Err = edgeFunctionsClient.UpdateEdgeFunctionCode(change.Function.Name, plan.Target, change.Archive)
+			}
+		}
+		if result.Err == nil && promoted.Version != 0 {
+			targetVersions[change.Function.Name] = promoted.Version
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	for _, change := range plan.Aliases {
+		result := Result{Resource: "edge function alias", Name: change.Function + ":" + change.Alias.Name, Action: change.Action}
+		alias := *change.Alias
+		if change.FollowsFunction {
+			version, ok := targetVersions[change.Function]
+			if !ok {
+				result.Err = fmt.Errorf("edge function %s was not promoted, so its version in %s is unknown", change.Function, plan.Target)
+				report.Results = append(report.Results, result)
+				continue
+			}
+			alias.FunctionVersion = strconv.Itoa(version)
+		}
+		if change.Action == ActionCreate {
+			_, _, result.Err = edgeFunctionsClient.CreateEdgeFunctionAlias(change.Function, plan.Target, &alias)
+		} else {
+			_, _, result.Err = edgeFunctionsClient.UpdateEdgeFunctionAlias(change.Function, plan.Target, alias.Name, &alias)
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	report.DeliveryServiceInstances = configurationClient.ApplyPlan(plan.DeliveryServiceInstances)
+	return report
+}
@@ -0,0 +1,188 @@
+package promote
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/llnw/llnw-sdk-go/configuration"
+	"github.com/llnw/llnw-sdk-go/edgefunctions"
+)
+
+func TestRewriteDeliveryServiceInstanceBody(t *testing.T) {
+	body := &configuration.DeliveryServiceInstanceBody{
+		PublishedHostname: "www.staging.example.com",
+		PublishedURLPath:  "/staging/video",
+		SourceHostname:    "origin.staging.example.com",
+		SourceURLPath:     "/staging",
+		ProtocolSets: []configuration.ProtocolSet{{
+			PublishedProtocol: "HTTPS",
+			SourceProtocol:    "HTTPS",
+			Options:           []configuration.Option{{Name: "req_send_header", Parameters: []interface{}{"X-Env", "staging"}}},
+		}},
+	}
+	rules := []RewriteRule{
+		{Target: TargetPublishedHostname, Old: "staging.", New: ""},
+		{Target: TargetSourceHostname, Old: "staging", New: "prod"},
+		{Target: TargetURLPath, Old: "/staging", New: "/prod"},
+		{Target: TargetSourceHostname, Old: "", New: "x"},
+	}
+
+	rewritten := RewriteDeliveryServiceInstanceBody(body, rules)
+	if rewritten.PublishedHostname != "www.example.com" || rewritten.SourceHostname != "origin.prod.example.com" ||
+		rewritten.PublishedURLPath != "/prod/video" || rewritten.SourceURLPath != "/prod" {
+		t.Errorf("RewriteDeliveryServiceInstanceBody() = %+v", rewritten)
+	}
+
+	rewritten.ProtocolSets[0].Options[0].Parameters[1] = "prod"
+	rewritten.ProtocolSets[0].PublishedProtocol = "HTTP"
+	if body.ProtocolSets[0].Options[0].Parameters[1] != "staging" || body.ProtocolSets[0].PublishedProtocol != "HTTPS" {
+		t.Errorf("editing the rewritten body changed the source body to %+v", body.ProtocolSets[0])
+	}
+}
+
+func TestRewriteEdgeFunction(t *testing.T) {
+	edgeFunction := &edgefunctions.EdgeFunction{
+		Name:            "auth",
+		Handler:         "index.handler",
+		FunctionArchive: []byte("zip"),
+		Sha256:          "abc",
+		RevisionID:      4,
+		Version:         3,
+		EnvironmentVariables: []edgefunctions.EnvironmentVariable{
+			{Name: "API_URL", Value: "https://api.staging.example.com"},
+			{Name: "CDN_URL", Value: "https://cdn.staging.example.com"},
+		},
+	}
+	rewritten := RewriteEdgeFunction(edgeFunction, []RewriteRule{
+		{Target: TargetEnvironmentVariable, Name: "API_URL", Old: "staging", New: "prod"},
+		{Target: TargetEnvironmentVariable, Old: "", New: "x"},
+	})
+
+	want := []edgefunctions.EnvironmentVariable{
+		{Name: "API_URL", Value: "https://api.prod.example.com"},
+		{Name: "CDN_URL", Value: "https://cdn.staging.example.com"},
+	}
+	if !reflect.DeepEqual(rewritten.EnvironmentVariables, want) {
+		t.Errorf("environment variables = %+v, want %+v", rewritten.EnvironmentVariables, want)
+	}
+	if rewritten.FunctionArchive != nil || rewritten.Sha256 != "" || rewritten.RevisionID != 0 || rewritten.Version != 0 {
+		t.Errorf("RewriteEdgeFunction() kept the archive or server-only fields: %+v", rewritten)
+	}
+	if edgeFunction.EnvironmentVariables[0].Value != "https://api.staging.example.com" {
+		t.Errorf("RewriteEdgeFunction() changed the source function to %+v", edgeFunction.EnvironmentVariables)
+	}
+}
+
+func TestValidateRewriteRules(t *testing.T) {
+	tests := []struct {
+		rule  RewriteRule
+		valid bool
+	}{
+		{rule: RewriteRule{Target: TargetSourceHostname, Old: "staging", New: "prod"}, valid: true},
+		{rule: RewriteRule{Target: TargetURLPath, Old: "/staging", New: ""}, valid: true},
+		{rule: RewriteRule{Target: TargetSourceHostname, Old: "", New: "prod"}},
+		{rule: RewriteRule{Target: "body", Old: "staging", New: "prod"}},
+	}
+	for _, test := range tests {
+		if err := ValidateRewriteRules([]RewriteRule{test.rule}); (err == nil) != test.valid {
+			t.Errorf("ValidateRewriteRules(%+v) = %v, want valid %v", test.rule, err, test.valid)
+		}
+	}
+
+	// the rules are checked before anything is fetched
+	if _, err := NewPlan(nil, nil, "staging", "prod", Selection{}, []RewriteRule{{Target: TargetSourceHostname}}); err == nil {
+		t.Error("NewPlan() accepted a rule with an empty Old")
+	}
+}
+
+func TestNewPlan(t *testing.T) {
+	deliveryServiceInstances := map[string][]configuration.DeliveryServiceInstance{
+		"staging": {
+			{UUID: "s1", Body: configuration.DeliveryServiceInstanceBody{PublishedHostname: "www.staging.example.com", PublishedURLPath: "/", SourceHostname: "origin.staging.example.com"}},
+			{UUID: "s2", Body: configuration.DeliveryServiceInstanceBody{PublishedHostname: "img.staging.example.com", PublishedURLPath: "/", SourceHostname: "origin.staging.example.com"}},
+		},
+		"prod": {
+			{UUID: "p1", Body: configuration.DeliveryServiceInstanceBody{PublishedHostname: "www.example.com", PublishedURLPath: "/", SourceHostname: "old.example.com"}},
+		},
+	}
+	configurationServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/svcinst/delivery/shortname/staging":
+			json.NewEncoder(w).Encode(configuration.DeliveryServiceInstancesResponse{Results: deliveryServiceInstances["staging"]})
+		case "/svcinst/delivery/shortname/prod":
+			json.NewEncoder(w).Encode(configuration.DeliveryServiceInstancesResponse{Results: deliveryServiceInstances["prod"]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer configurationServer.Close()
+
+	edgeFunctionsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/prod/functions":
+			json.NewEncoder(w).Encode(edgefunctions.EdgeFunctionsResponse{})
+		case "/staging/functions/auth":
+			json.NewEncoder(w).Encode(edgefunctions.EdgeFunction{
+				Name:                 "auth",
+				Version:              3,
+				FunctionArchive:      []byte("zip"),
+				EnvironmentVariables: []edgefunctions.EnvironmentVariable{{Name: "API_URL", Value: "https://api.staging.example.com"}},
+			})
+		case "/staging/functions/auth/aliases":
+			json.NewEncoder(w).Encode(edgefunctions.EdgeFunctionAliasesResponse{Aliases: []edgefunctions.EdgeFunctionAlias{
+				{Name: "live", FunctionVersion: "3", RevisionID: 9},
+				{Name: "previous", FunctionVersion: "2"},
+				{Name: "latest", FunctionVersion: "$LATEST"},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer edgeFunctionsServer.Close()
+
+	plan, err := NewPlan(
+		configuration.NewClientOverrideBaseUrl("user", "key", configurationServer.URL),
+		edgefunctions.NewClientOverrideBaseUrl("user", "key", edgeFunctionsServer.URL),
+		"staging", "prod",
+		Selection{EdgeFunctions: []string{"auth"}},
+		[]RewriteRule{
+			{Target: TargetPublishedHostname, Old: "staging.", New: ""},
+			{Target: TargetSourceHostname, Old: "origin.staging.", New: "origin."},
+			{Target: TargetEnvironmentVariable, Old: "staging", New: "prod"},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.EdgeFunctions) != 1 || plan.EdgeFunctions[0].Action != ActionCreate || string(plan.EdgeFunctions[0].Archive) != "zip" ||
+		plan.EdgeFunctions[0].Function.EnvironmentVariables[0].Value != "https://api.prod.example.com" {
+		t.Errorf("edge function changes = %+v", plan.EdgeFunctions)
+	}
+
+	wantAliases := []AliasChange{
+		{Action: ActionCreate, Function: "auth", Alias: &edgefunctions.EdgeFunctionAlias{Name: "live"}, FollowsFunction: true},
+		{Action: ActionCreate, Function: "auth", Alias: &edgefunctions.EdgeFunctionAlias{Name: "latest", FunctionVersion: "$LATEST"}},
+	}
+	if !reflect.DeepEqual(plan.Aliases, wantAliases) {
+		t.Errorf("alias changes = %+v, want %+v", plan.Aliases, wantAliases)
+	}
+	if want := []RefusedAlias{{Function: "auth", Alias: "previous", FunctionVersion: "2"}}; !reflect.DeepEqual(plan.RefusedAliases, want) {
+		t.Errorf("refused aliases = %+v, want %+v", plan.RefusedAliases, want)
+	}
+
+	var changes []string
+	for _, change := range plan.DeliveryServiceInstances.Changes {
+		changes = append(changes, change.Action+" "+change.Key.String()+" from "+change.Desired.SourceHostname)
+	}
+	wantChanges := []string{
+		configuration.PlanActionCreate + " img.example.com/ from origin.example.com",
+		configuration.PlanActionUpdate + " www.example.com/ from origin.example.com",
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("delivery service instance changes = %q, want %q", changes, wantChanges)
+	}
+}
@@ -0,0 +1,378 @@
+package snapshot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/llnw/llnw-sdk-go/configuration"
+	"github.com/llnw/llnw-sdk-go/edgefunctions"
+)
+
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictFail      = "fail"
+)
+
+const (
+	ResourceEdgeFunction            = "edgeFunction"
+	ResourceEdgeFunctionAlias       = "edgeFunctionAlias"
+	ResourceRealtimeStreamingSlot   = "realtimeStreamingSlot"
+	ResourceDeliveryServiceInstance = "deliveryServiceInstance"
+)
+
+const (
+	RestoreActionCreate    = "create"
+	RestoreActionOverwrite = "overwrite"
+	RestoreActionSkip      = "skip"
+)
+
+// RestoreOptions controls how a snapshot is restored. ConflictPolicy decides what happens to resources that already
+// exist in the target shortname and defaults to ConflictSkip.
+type RestoreOptions struct {
+	DryRun         bool
+	ConflictPolicy string
+}
+
+// RestoreStep is a single resource restored, or planned to be restored when DryRun is set
+type RestoreStep struct {
+	Resource string
+	Name     string
+	Action   string
+	Err      error
+	apply    func() error
+}
+
+// RestoreReport lists every step of a restore, in the order they were applied
+type RestoreReport struct {
+	Shortname string
+	DryRun    bool
+	Steps     []RestoreStep
+}
+
+// Err summarises the failed steps, or returns nil if every step succeeded
+func (r *RestoreReport) Err() error {
+	var messages []string
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			messages = append(messages, fmt.Sprintf("%s %s %s: %s", step.Action, step.Resource, step.Name, step.Err))
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d restore steps failed: %s", len(messages), len(r.Steps), strings.Join(messages, "; "))
+}
+
+// Restore recreates the snapshot in dir in the said shortname. Edge functions are restored first, then their
+// aliases, realtime streaming slots and finally delivery service instances, which may refer to edge functions.
+// Aliases of the snapshot version of an edge function point at the version restoring it produces, and aliases of other
+// versions are refused. Delivery service instances that were disabled when the snapshot was taken are disabled again
+// once written. Delivery service instances are matched by hostname and URL path, so nothing is restored if two of
+// them share both. With ConflictFail nothing is changed if any resource already exists. Failed steps do not stop later
+// steps and are reported in the returned RestoreReport.
+func Restore(configurationClient *configuration.ConfigurationClient, edgeFunctionsClient *edgefunctions.EdgeFunctionsClient, dir string, shortname string, options RestoreOptions) (*RestoreReport, error) {
+	policy := options.ConflictPolicy
+	if policy == "" {
+		policy = ConflictSkip
+	}
+	if policy != ConflictSkip && policy != ConflictOverwrite && policy != ConflictFail {
+		return nil, fmt.Errorf("unknown conflict policy %q", policy)
+	}
+
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RestoreReport{Shortname: shortname, DryRun: options.DryRun}
+	var conflicts []string
+	addStep := func(resource string, name string, exists bool, create func() error, overwrite func() error) {
+		step := RestoreStep{Resource: resource, Name: name, Action: RestoreActionCreate, apply: create}
+		if exists {
+			conflicts = append(conflicts, resource+" "+name)
+			if policy == ConflictOverwrite {
+				step.Action, step.apply = RestoreActionOverwrite, overwrite
+			} else {
+				step.Action, step.apply = RestoreActionSkip, nil
+			}
+		}
+		report.Steps = append(report.Steps, step)
+	}
+
+	// refuseStep reports a resource that cannot be restored, without changing anything
+	refuseStep := func(resource string, name string, err error) {
+		report.Steps = append(report.Steps, RestoreStep{Resource: resource, Name: name, Action: RestoreActionSkip, Err: err})
+	}
+
+	if err := planEdgeFunctions(edgeFunctionsClient, manifest, dir, shortname, addStep, refuseStep); err != nil {
+		return nil, err
+	}
+	if err := planRealtimeStreamingSlots(configurationClient, manifest, dir, shortname, addStep); err != nil {
+		return nil, err
+	}
+	if err := planDeliveryServiceInstances(configurationClient, manifest, dir, shortname, addStep); err != nil {
+		return nil, err
+	}
+
+	if policy == ConflictFail && len(conflicts) > 0 {
+		return report, fmt.Errorf("%d resources already exist in %s: %s", len(conflicts), shortname, strings.Join(conflicts, ", "))
+	}
+	if options.DryRun {
+		return report, nil
+	}
+
+	for i := range report.Steps {
+		if report.Steps[i].apply != nil {
+			report.Steps[i].Err = report.Steps[i].apply()
+		}
+	}
+	return report, nil
+}
+
+type addStepFunc func(resource string, name string, exists bool, create func() error, overwrite func() error)
+
+type refuseStepFunc func(resource string, name string, err error)
+
+func planEdgeFunctions(edgeFunctionsClient *edgefunctions.EdgeFunctionsClient, manifest *Manifest, dir string, shortname string, addStep addStepFunc, refuseStep refuseStepFunc) error {
+	existingFunctions, _, err := edgeFunctionsClient.ListEdgeFunctions(shortname)
+	if err != nil {
+		return fmt.Errorf("listing edge functions: %s", err)
+	}
+	functionExists := map[string]bool{}
+	for _, existingFunction := range existingFunctions {
+		functionExists[existingFunction.Name] = true
+	}
+
+	var aliasSteps []func()
+	for _, entry := range manifest.EdgeFunctions {
+		edgeFunction := &edgefunctions.EdgeFunction{}
+		if err := readJSONFile(dir, entry.Configuration, edgeFunction); err != nil {
+			return err
+		}
+		snapshotVersion := strconv.Itoa(edgeFunction.Version)
+		edgeFunction.Sha256, edgeFunction.RevisionID, edgeFunction.Version = "", 0, 0
+
+		var archive []byte
+		if entry.Archive != "" {
+			if archive, err = ioutil.ReadFile(filepath.Join(dir, entry.Archive)); err != nil {
+				return err
+			}
+		}
+
+		// version numbers are per shortname, so aliases of the snapshot version point at whatever version restoring the
+		// function produces
+		name := edgeFunction.Name
+		restoredVersion := ""
+		restored := func(restoredFunction *edgefunctions.EdgeFunction) {
+			if restoredFunction.Version != 0 {
+				restoredVersion = strconv.Itoa(restoredFunction.Version)
+			}
+		}
+		addStep(ResourceEdgeFunction, name, functionExists[name], func() error {
+			withArchive := *edgeFunction
+			withArchive.FunctionArchive = archive
+			created, _, err := edgeFunctionsClient.CreateEdgeFunction(shortname, &withArchive)
+			if err != nil {
+				return err
+			}
+			restored(created)
+			return nil
+		}, func() error {
+			updated, _, err := edgeFunctionsClient.UpdateEdgeFunctionConfiguration(name, shortname, edgeFunction)
+			if err != nil {
+				return err
+			}
+			if len(archive) > 0 {
+				if updated, _, err = edgeFunctionsClient.UpdateEdgeFunctionCode(name, shortname, archive); err != nil {
+					return err
+				}
+			}
+			restored(updated)
+			return nil
+		})
+
+		aliasExists := map[string]bool{}
+		if functionExists[name] {
+			existingAliases, _, err := edgeFunctionsClient.ListEdgeFunctionAliases(name, shortname)
+			if err != nil {
+				return fmt.Errorf("listing aliases of edge function %s: %s", name, err)
+			}
+			for _, existingAlias := range existingAliases {
+				aliasExists[existingAlias.Name] = true
+			}
+		}
+		for _, aliasFile := range entry.Aliases {
+			alias := &edgefunctions.EdgeFunctionAlias{}
+			if err := readJSONFile(dir, aliasFile, alias); err != nil {
+				return err
+			}
+			alias.RevisionID = 0
+			aliasName := name + ":" + alias.Name
+			_, err := strconv.Atoi(alias.FunctionVersion)
+			followsFunction := err == nil
+			if followsFunction && alias.FunctionVersion != snapshotVersion {
+				aliasSteps = append(aliasSteps, func() {
+					refuseStep(ResourceEdgeFunctionAlias, aliasName, fmt.Errorf("points at version %s, but the snapshot only holds version %s", alias.FunctionVersion, snapshotVersion))
+				})
+				continue
+			}
+			withVersion := func() (*edgefunctions.EdgeFunctionAlias, error) {
+				if !followsFunction {
+					return alias, nil
+				}
+				if restoredVersion == "" {
+					return nil, fmt.Errorf("edge function %s was not restored, so its version in %s is unknown", name, shortname)
+				}
+				restoredAlias := *alias
+				restoredAlias.FunctionVersion = restoredVersion
+				return &restoredAlias, nil
+			}
+			aliasSteps = append(aliasSteps, func() {
+				addStep(ResourceEdgeFunctionAlias, aliasName, aliasExists[alias.Name], func() error {
+					restoredAlias, err := withVersion()
+					if err != nil {
+						return err
+					}
+					_, _, err = edgeFunctionsClient.CreateEdgeFunctionAlias(name, shortname, restoredAlias)
+					return err
+				}, func() error {
+					restoredAlias, err := withVersion()
+					if err != nil {
+						return err
+					}
+					_, _, err = edgeFunctionsClient.UpdateEdgeFunctionAlias(name, shortname, alias.Name, restoredAlias)
+					return err
+				})
+			})
+		}
+	}
+
+	for _, aliasStep := range aliasSteps {
+		aliasStep()
+	}
+	return nil
+}
+
+func planRealtimeStreamingSlots(configurationClient *configuration.ConfigurationClient, manifest *Manifest, dir string, shortname string, addStep addStepFunc) error {
+	existingSlots, _, err := configurationClient.ListRealtimeStreamingSlots(shortname)
+	if err != nil {
+		return fmt.Errorf("listing realtime streaming slots: %s", err)
+	}
+	existingSlotIds := map[string]string{}
+	for _, existingSlot := range existingSlots {
+		existingSlotIds[existingSlot.Name] = existingSlot.Id
+	}
+
+	for _, file := range manifest.RealtimeStreamingSlots {
+		slot := &configuration.RealtimeStreamingSlot{}
+		if err := readJSONFile(dir, file, slot); err != nil {
+			return err
+		}
+		slot.Id, slot.State = "", ""
+
+		existingId, exists := existingSlotIds[slot.Name]
+		addStep(ResourceRealtimeStreamingSlot, slot.Name, exists, func() error {
+			_, _, err := configurationClient.CreateRealtimeStreamingSlot(shortname, slot)
+			return err
+		}, func() error {
+			update := &configuration.RealtimeStreamingSlotUpdate{
+				Profiles:          slot.Profiles,
+				IPGeoMatch:        &slot.IPGeoMatch,
+				MediaVaultEnabled: &slot.MediaVaultEnabled,
+			}
+			// secrets missing from the snapshot are left as they are rather than cleared
+			if slot.Password != "" {
+				update.Password = &slot.Password
+			}
+			if slot.MediaVaultSecretKey != "" {
+				update.MediaVaultSecretKey = &slot.MediaVaultSecretKey
+			}
+			_, _, err := configurationClient.UpdateRealtimeStreamingSlot(existingId, shortname, update)
+			return err
+		})
+	}
+	return nil
+}
+
+func planDeliveryServiceInstances(configurationClient *configuration.ConfigurationClient, manifest *Manifest, dir string, shortname string, addStep addStepFunc) error {
+	existingInstances, _, err := configurationClient.ListDeliveryServiceInstances(shortname)
+	if err != nil {
+		return fmt.Errorf("listing delivery service instances: %s", err)
+	}
+	existing := map[configuration.DeliveryServiceInstanceKey]*configuration.DeliveryServiceInstance{}
+	for i := range existingInstances {
+		key := configuration.DeliveryServiceInstanceKeyOf(&existingInstances[i].Body)
+		if other, ok := existing[key]; ok {
+			return fmt.Errorf("delivery service instances %s and %s are both published at %s", other.UUID, existingInstances[i].UUID, key)
+		}
+		existing[key] = &existingInstances[i]
+	}
+
+	disabled := map[string]bool{}
+	for _, file := range manifest.DisabledDeliveryServiceInstances {
+		disabled[file] = true
+	}
+
+	files := map[configuration.DeliveryServiceInstanceKey]string{}
+	for _, file := range manifest.DeliveryServiceInstances {
+		document, err := configuration.ReadDeliveryServiceInstanceFile(filepath.Join(dir, file))
+		if err != nil {
+			return err
+		}
+
+		body := &document.Body
+		key := configuration.DeliveryServiceInstanceKeyOf(body)
+		if other, ok := files[key]; ok {
+			return fmt.Errorf("%s and %s are both published at %s", other, file, key)
+		}
+		files[key] = file
+		existingInstance, exists := existing[key]
+		disable := disabled[file]
+		// instances restored into the shortname they were taken from keep the accounts sharing them, which may not
+		// exist for another shortname
+		accounts := []configuration.Account{{Shortname: shortname}}
+		if shortname == manifest.Shortname && len(document.Accounts) > 0 {
+			accounts = document.Accounts
+		}
+		addStep(ResourceDeliveryServiceInstance, key.String(), exists, func() error {
+			created, _, err := configurationClient.CreateDeliveryServiceInstanceWithAccounts(body, accounts)
+			if created == nil || created.UUID == "" {
+				return err
+			}
+			return disableDeliveryServiceInstance(configurationClient, created.UUID, disable, err)
+		}, func() error {
+			// keep the owners of instances shared with other accounts
+			var updated *configuration.DeliveryServiceInstance
+			var err error
+			if len(existingInstance.Accounts) == 0 {
+				updated, _, err = configurationClient.UpdateDeliveryServiceInstance(existingInstance.UUID, body, shortname)
+			} else {
+				updated, _, err = configurationClient.UpdateDeliveryServiceInstanceWithAccounts(existingInstance.UUID, body, existingInstance.Accounts)
+			}
+			if updated == nil {
+				return err
+			}
+			return disableDeliveryServiceInstance(configurationClient, existingInstance.UUID, disable, err)
+		})
+	}
+	return nil
+}
+
+// disableDeliveryServiceInstance disables an instance restored from a disabled one, so that it does not receive
+// traffic it did not receive when the snapshot was taken. err is the error reported by the write that restored it.
+func disableDeliveryServiceInstance(configurationClient *configuration.ConfigurationClient, uuid string, disable bool, err error) error {
+	if !disable {
+		return err
+	}
+	if _, _, disableErr := configurationClient.DisableDeliveryServiceInstance(uuid); disableErr != nil && !configuration.IsResponseCoercionError(disableErr) {
+		if err != nil {
+			return fmt.Errorf("%s; disabling: %s", err, disableErr)
+		}
+		return fmt.Errorf("disabling: %s", disableErr)
+	}
+	return err
+}
@@ -0,0 +1,234 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/llnw/llnw-sdk-go/configuration"
+	"github.com/llnw/llnw-sdk-go/edgefunctions"
+)
+
+// writeSnapshot writes a snapshot of the said shortname holding the documents, keyed by file, to a new directory.
+// Files listed in disabled are recorded as disabled instances.
+func writeSnapshot(t *testing.T, shortname string, documents map[string]*configuration.DeliveryServiceInstanceCreateRequest, disabled ...string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := &Manifest{Version: ManifestVersion, Shortname: shortname, DisabledDeliveryServiceInstances: disabled}
+	for file, document := range documents {
+		document := document
+		if err := writeFile(dir, file, func(path string) error {
+			return configuration.WriteDeliveryServiceInstanceFile(path, document)
+		}); err != nil {
+			t.Fatal(err)
+		}
+		manifest.DeliveryServiceInstances = append(manifest.DeliveryServiceInstances, file)
+	}
+	sort.Strings(manifest.DeliveryServiceInstances)
+	if err := writeJSONFile(dir, ManifestFile, manifest); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// fakeAPI serves the delivery service instances of a shortname, which has no slot or edge function, and records the
+// requests changing anything
+type fakeAPI struct {
+	instances []configuration.DeliveryServiceInstance
+	lock      sync.Mutex
+	writes    []string
+}
+
+func (a *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/svcinst/delivery/shortname/"):
+			json.NewEncoder(w).Encode(configuration.DeliveryServiceInstancesResponse{Results: a.instances})
+		case strings.HasSuffix(r.URL.Path, "/slots"):
+			json.NewEncoder(w).Encode(configuration.RealtimeStreamingSlotsResponse{})
+		case strings.HasSuffix(r.URL.Path, "/functions"):
+			json.NewEncoder(w).Encode(edgefunctions.EdgeFunctionsResponse{})
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	write := r.Method + " " + r.URL.Path
+	if r.Method == http.MethodPost && r.URL.Path == "/svcinst/delivery" {
+		request := &configuration.DeliveryServiceInstanceCreateRequest{}
+		json.NewDecoder(r.Body).Decode(request)
+		for _, account := range request.Accounts {
+			write += " " + account.Shortname
+		}
+		created := configuration.DeliveryServiceInstance{UUID: "new-" + strconv.Itoa(len(a.writes)+1), Accounts: request.Accounts, Body: request.Body}
+		json.NewEncoder(w).Encode(created)
+	} else {
+		w.Write([]byte("{}"))
+	}
+	a.writes = append(a.writes, write)
+}
+
+func (a *fakeAPI) restore(dir string, shortname string, options RestoreOptions) (*RestoreReport, error) {
+	server := httptest.NewServer(a)
+	defer server.Close()
+	return Restore(
+		configuration.NewClientOverrideBaseUrl("user", "key", server.URL),
+		edgefunctions.NewClientOverrideBaseUrl("user", "key", server.URL),
+		dir, shortname, options,
+	)
+}
+
+func TestRestorePlan(t *testing.T) {
+	t.Parallel()
+	dir := writeSnapshot(t, "staging", map[string]*configuration.DeliveryServiceInstanceCreateRequest{
+		"delivery/a.json": {Body: configuration.DeliveryServiceInstanceBody{PublishedHostname: "www.example.com", PublishedURLPath: "/", SourceHostname: "origin.example.com"}},
+		"delivery/b.json": {Body: configuration.DeliveryServiceInstanceBody{PublishedHostname: "img.example.com", PublishedURLPath: "/", SourceHostname: "origin.example.com"}},
+	})
+	defer os.RemoveAll(dir)
+
+	// add an edge function whose aliases follow the snapshot version and an older one
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := EdgeFunctionEntry{
+		Name:          "auth",
+		Configuration: filepath.Join("edgefunctions", "auth", "function.json"),
+		Aliases:       []string{filepath.Join("edgefunctions", "auth", "aliases", "live.json"), filepath.Join("edgefunctions", "auth", "aliases", "previous.json")},
+	}
+	manifest.EdgeFunctions = []EdgeFunctionEntry{entry}
+	for file, value := range map[string]interface{}{
+		ManifestFile:        manifest,
+		entry.Configuration: &edgefunctions.EdgeFunction{Name: "auth", Version: 3},
+		entry.Aliases[0]:    &edgefunctions.EdgeFunctionAlias{Name: "live", FunctionVersion: "3"},
+		entry.Aliases[1]:    &edgefunctions.EdgeFunctionAlias{Name: "previous", FunctionVersion: "2"},
+	} {
+		if err := writeJSONFile(dir, file, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	api := &fakeAPI{instances: []configuration.DeliveryServiceInstance{
+		{UUID: "p1", Body: configuration.DeliveryServiceInstanceBody{PublishedHostname: "www.example.com", PublishedURLPath: "/", SourceHostname: "old.example.com"}},
+	}}
+	report, err := api.restore(dir, "prod", RestoreOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []string
+	for _, step := range report.Steps {
+		steps = append(steps, step.Action+" "+step.Resource+" "+step.Name)
+		if (step.Err != nil) != (step.Name == "auth:previous") {
+			t.Errorf("step %s %s %s failed with %v", step.Action, step.Resource, step.Name, step.Err)
+		}
+	}
+	wantSteps := []string{
+		"create edgeFunction auth",
+		"create edgeFunctionAlias auth:live",
+		"skip edgeFunctionAlias auth:previous",
+		"skip deliveryServiceInstance www.example.com/",
+		"create deliveryServiceInstance img.example.com/",
+	}
+	if !reflect.DeepEqual(steps, wantSteps) {
+		t.Errorf("steps = %q, want %q", steps, wantSteps)
+	}
+
+	if _, err := api.restore(dir, "prod", RestoreOptions{ConflictPolicy: ConflictFail}); err == nil {
+		t.Error("Restore() with ConflictFail ignored the existing www.example.com/")
+	}
+	if len(api.writes) > 0 {
+		t.Errorf("planning made changes: %q", api.writes)
+	}
+}
+
+func TestRestoreDeliveryServiceInstances(t *testing.T) {
+	t.Parallel()
+	documents := map[string]*configuration.DeliveryServiceInstanceCreateRequest{
+		"delivery/a.json": {
+			Body:     configuration.DeliveryServiceInstanceBody{PublishedHostname: "www.example.com", PublishedURLPath: "/", SourceHostname: "origin.example.com"},
+			Accounts: []configuration.Account{{Shortname: "staging"}, {Shortname: "partner"}},
+		},
+		"delivery/b.json": {
+			Body:     configuration.DeliveryServiceInstanceBody{PublishedHostname: "img.example.com", PublishedURLPath: "/", SourceHostname: "origin.example.com"},
+			Accounts: []configuration.Account{{Shortname: "staging"}},
+		},
+	}
+
+	// instances keep the accounts sharing them only in the shortname they were taken from
+	tests := map[string][]string{
+		"staging": {"POST /svcinst/delivery staging partner", "POST /svcinst/delivery staging", "PUT /svcinst/delivery/new-2/disable"},
+		"prod":    {"POST /svcinst/delivery prod", "POST /svcinst/delivery prod", "PUT /svcinst/delivery/new-2/disable"},
+	}
+	for shortname, want := range tests {
+		shortname, want := shortname, want
+		t.Run(shortname, func(t *testing.T) {
+			t.Parallel()
+			dir := writeSnapshot(t, "staging", documents, "delivery/b.json")
+			defer os.RemoveAll(dir)
+
+			api := &fakeAPI{}
+			report, err := api.restore(dir, shortname, RestoreOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := report.Err(); err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(api.writes, want) {
+				t.Errorf("requests = %q, want %q", api.writes, want)
+			}
+		})
+	}
+}
+
+func TestRestoreRejectsInstancesSharingAKey(t *testing.T) {
+	t.Parallel()
+	www := configuration.DeliveryServiceInstanceBody{PublishedHostname: "www.example.com", PublishedURLPath: "/", SourceHostname: "origin.example.com"}
+	wwwHTTP := www
+	wwwHTTP.PublishedHostname = "WWW.example.com"
+	wwwHTTP.ProtocolSets = []configuration.ProtocolSet{{PublishedProtocol: "HTTP", SourceProtocol: "HTTP"}}
+
+	tests := map[string]struct {
+		documents map[string]*configuration.DeliveryServiceInstanceCreateRequest
+		live      []configuration.DeliveryServiceInstance
+	}{
+		"snapshot": {
+			documents: map[string]*configuration.DeliveryServiceInstanceCreateRequest{"delivery/a.json": {Body: www}, "delivery/b.json": {Body: wwwHTTP}},
+		},
+		"live": {
+			documents: map[string]*configuration.DeliveryServiceInstanceCreateRequest{"delivery/a.json": {Body: www}},
+			live:      []configuration.DeliveryServiceInstance{{UUID: "p1", Body: www}, {UUID: "p2", Body: wwwHTTP}},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			dir := writeSnapshot(t, "staging", test.documents)
+			defer os.RemoveAll(dir)
+
+			api := &fakeAPI{instances: test.live}
+			if _, err := api.restore(dir, "prod", RestoreOptions{}); err == nil || !strings.Contains(err.Error(), "both published at www.example.com/") {
+				t.Errorf("Restore() error = %v", err)
+			}
+			if len(api.writes) > 0 {
+				t.Errorf("Restore() made changes: %q", api.writes)
+			}
+		})
+	}
+}
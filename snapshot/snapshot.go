@@ -0,0 +1,190 @@
+// Package snapshot saves every delivery service instance, realtime streaming slot, edge function and edge function
+// alias of a shortname to a directory, and restores such a directory into the same or another shortname. Snapshots
+// hold passwords, secret keys and environment variables, so their files and directories are readable by their owner
+// only.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/llnw/llnw-sdk-go/configuration"
+	"github.com/llnw/llnw-sdk-go/edgefunctions"
+)
+
+// ManifestVersion is the version of the snapshot layout written by Create
+const ManifestVersion = 1
+
+// ManifestFile is the name of the manifest within a snapshot directory
+const ManifestFile = "manifest.json"
+
+// Manifest lists the files of a snapshot, relative to the snapshot directory. DisabledDeliveryServiceInstances repeats
+// the files of DeliveryServiceInstances whose instances were disabled.
+type Manifest struct {
+	Version                          int                 `json:"version"`
+	Shortname                        string              `json:"shortname"`
+	CreatedAt                        time.Time           `json:"createdAt"`
+	DeliveryServiceInstances         []string            `json:"deliveryServiceInstances"`
+	DisabledDeliveryServiceInstances []string            `json:"disabledDeliveryServiceInstances,omitempty"`
+	RealtimeStreamingSlots           []string            `json:"realtimeStreamingSlots"`
+	EdgeFunctions                    []EdgeFunctionEntry `json:"edgeFunctions"`
+}
+
+// EdgeFunctionEntry lists the files of a single edge function
+type EdgeFunctionEntry struct {
+	Name          string   `json:"name"`
+	Configuration string   `json:"configuration"`
+	Archive       string   `json:"archive,omitempty"`
+	Aliases       []string `json:"aliases"`
+}
+
+// Create writes every delivery service instance, realtime streaming slot, edge function and edge function alias of
+// the shortname to dir, which is created if needed, and returns the manifest written alongside them
+func Create(configurationClient *configuration.ConfigurationClient, edgeFunctionsClient *edgefunctions.EdgeFunctionsClient, shortname string, dir string) (*Manifest, error) {
+	manifest := &Manifest{
+		Version:   ManifestVersion,
+		Shortname: shortname,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	instances, _, err := configurationClient.ListDeliveryServiceInstances(shortname)
+	if err != nil {
+		return nil, fmt.Errorf("listing delivery service instances: %s", err)
+	}
+	for i := range instances {
+		file := filepath.Join("delivery", safeFileName(instances[i].UUID)+".json")
+		document := configuration.DeliveryServiceInstanceDocumentOf(&instances[i])
+		if err := writeFile(dir, file, func(path string) error {
+			return configuration.WriteDeliveryServiceInstanceFile(path, document)
+		}); err != nil {
+			return nil, err
+		}
+		manifest.DeliveryServiceInstances = append(manifest.DeliveryServiceInstances, file)
+		if !instances[i].IsEnabled {
+			manifest.DisabledDeliveryServiceInstances = append(manifest.DisabledDeliveryServiceInstances, file)
+		}
+	}
+
+	slots, _, err := configurationClient.ListRealtimeStreamingSlots(shortname)
+	if err != nil {
+		return nil, fmt.Errorf("listing realtime streaming slots: %s", err)
+	}
+	for _, slot := range slots {
+		file := filepath.Join("realtime-streaming", safeFileName(slot.Id)+".json")
+		if err := writeJSONFile(dir, file, slot); err != nil {
+			return nil, err
+		}
+		manifest.RealtimeStreamingSlots = append(manifest.RealtimeStreamingSlots, file)
+	}
+
+	functions, _, err := edgeFunctionsClient.ListEdgeFunctions(shortname)
+	if err != nil {
+		return nil, fmt.Errorf("listing edge functions: %s", err)
+	}
+	for _, listedFunction := range functions {
+		entry, err := snapshotEdgeFunction(edgeFunctionsClient, shortname, listedFunction.Name, dir)
+		if err != nil {
+			return nil, err
+		}
+		manifest.EdgeFunctions = append(manifest.EdgeFunctions, *entry)
+	}
+
+	if err := writeJSONFile(dir, ManifestFile, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func snapshotEdgeFunction(edgeFunctionsClient *edgefunctions.EdgeFunctionsClient, shortname string, name string, dir string) (*EdgeFunctionEntry, error) {
+	edgeFunction, _, err := edgeFunctionsClient.GetEdgeFunction(name, shortname)
+	if err != nil {
+		return nil, fmt.Errorf("fetching edge function %s: %s", name, err)
+	}
+
+	functionDir := filepath.Join("edgefunctions", safeFileName(name))
+	entry := &EdgeFunctionEntry{
+		Name:          name,
+		Configuration: filepath.Join(functionDir, "function.json"),
+	}
+
+	archive := edgeFunction.FunctionArchive
+	edgeFunction.FunctionArchive = nil
+	if err := writeJSONFile(dir, entry.Configuration, edgeFunction); err != nil {
+		return nil, err
+	}
+	if len(archive) > 0 {
+		entry.Archive = filepath.Join(functionDir, "archive.zip")
+		if err := writeFile(dir, entry.Archive, func(path string) error {
+			return ioutil.WriteFile(path, archive, 0600)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	aliases, _, err := edgeFunctionsClient.ListEdgeFunctionAliases(name, shortname)
+	if err != nil {
+		return nil, fmt.Errorf("listing aliases of edge function %s: %s", name, err)
+	}
+	for _, alias := range aliases {
+		file := filepath.Join(functionDir, "aliases", safeFileName(alias.Name)+".json")
+		if err := writeJSONFile(dir, file, alias); err != nil {
+			return nil, err
+		}
+		entry.Aliases = append(entry.Aliases, file)
+	}
+	return entry, nil
+}
+
+// ReadManifest loads the manifest of the snapshot in dir
+func ReadManifest(dir string) (*Manifest, error) {
+	manifest := &Manifest{}
+	if err := readJSONFile(dir, ManifestFile, manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version != ManifestVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d, expected %d", manifest.Version, ManifestVersion)
+	}
+	return manifest, nil
+}
+
+func safeFileName(name string) string {
+	return url.PathEscape(name)
+}
+
+func writeFile(dir string, file string, write func(path string) error) error {
+	path := filepath.Join(dir, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := write(path); err != nil {
+		return fmt.Errorf("writing %s: %s", path, err)
+	}
+	return nil
+}
+
+func writeJSONFile(dir string, file string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(dir, file, func(path string) error {
+		return ioutil.WriteFile(path, append(data, '\n'), 0600)
+	})
+}
+
+func readJSONFile(dir string, file string, value interface{}) error {
+	path := filepath.Join(dir, file)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("reading %s: %s", path, err)
+	}
+	return nil
+}
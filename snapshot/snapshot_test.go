@@ -0,0 +1,130 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/llnw/llnw-sdk-go/configuration"
+	"github.com/llnw/llnw-sdk-go/edgefunctions"
+)
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+	configurationServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/svcinst/delivery/shortname/staging":
+			json.NewEncoder(w).Encode(configuration.DeliveryServiceInstancesResponse{Results: []configuration.DeliveryServiceInstance{
+				{UUID: "a", IsEnabled: true, Accounts: []configuration.Account{{Shortname: "staging"}}, Body: configuration.DeliveryServiceInstanceBody{PublishedHostname: "www.example.com", PublishedURLPath: "/", SourceHostname: "origin.example.com"}},
+				{UUID: "b", Accounts: []configuration.Account{{Shortname: "staging"}}, Body: configuration.DeliveryServiceInstanceBody{PublishedHostname: "img.example.com", PublishedURLPath: "/", SourceHostname: "origin.example.com"}},
+			}})
+		case "/webrtc/shortname/staging/slots":
+			json.NewEncoder(w).Encode(configuration.RealtimeStreamingSlotsResponse{Slots: []configuration.RealtimeStreamingSlot{{Id: "slot-1", Name: "main", Password: "pw"}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer configurationServer.Close()
+
+	edgeFunctionsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/staging/functions":
+			json.NewEncoder(w).Encode(edgefunctions.EdgeFunctionsResponse{Functions: []edgefunctions.EdgeFunction{{Name: "auth"}}})
+		case "/staging/functions/auth":
+			json.NewEncoder(w).Encode(edgefunctions.EdgeFunction{Name: "auth", Version: 3, FunctionArchive: []byte("zip")})
+		case "/staging/functions/auth/aliases":
+			json.NewEncoder(w).Encode(edgefunctions.EdgeFunctionAliasesResponse{Aliases: []edgefunctions.EdgeFunctionAlias{{Name: "live", FunctionVersion: "3"}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer edgeFunctionsServer.Close()
+
+	root, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "staging")
+
+	manifest, err := Create(
+		configuration.NewClientOverrideBaseUrl("user", "key", configurationServer.URL),
+		edgefunctions.NewClientOverrideBaseUrl("user", "key", edgeFunctionsServer.URL),
+		"staging", dir,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Manifest{
+		Version:                          ManifestVersion,
+		Shortname:                        "staging",
+		CreatedAt:                        manifest.CreatedAt,
+		DeliveryServiceInstances:         []string{filepath.Join("delivery", "a.json"), filepath.Join("delivery", "b.json")},
+		DisabledDeliveryServiceInstances: []string{filepath.Join("delivery", "b.json")},
+		RealtimeStreamingSlots:           []string{filepath.Join("realtime-streaming", "slot-1.json")},
+		EdgeFunctions: []EdgeFunctionEntry{{
+			Name:          "auth",
+			Configuration: filepath.Join("edgefunctions", "auth", "function.json"),
+			Archive:       filepath.Join("edgefunctions", "auth", "archive.zip"),
+			Aliases:       []string{filepath.Join("edgefunctions", "auth", "aliases", "live.json")},
+		}},
+	}
+	if !reflect.DeepEqual(manifest, want) {
+		t.Errorf("Create() = %+v, want %+v", manifest, want)
+	}
+	read, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, manifest) {
+		t.Errorf("ReadManifest() = %+v, want %+v", read, manifest)
+	}
+
+	archive, err := ioutil.ReadFile(filepath.Join(dir, want.EdgeFunctions[0].Archive))
+	if err != nil || string(archive) != "zip" {
+		t.Errorf("archive = %q, %v", archive, err)
+	}
+	edgeFunction := &edgefunctions.EdgeFunction{}
+	if err := readJSONFile(dir, want.EdgeFunctions[0].Configuration, edgeFunction); err != nil || edgeFunction.FunctionArchive != nil {
+		t.Errorf("function.json = %+v, %v", edgeFunction, err)
+	}
+
+	// snapshots hold secrets, so nothing in them may be readable by anyone but their owner
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		mode := os.FileMode(0600)
+		if info.IsDir() {
+			mode = 0700
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s has mode %v, want %v", path, info.Mode().Perm(), mode)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadManifestRejectsOtherVersions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := writeJSONFile(dir, ManifestFile, &Manifest{Version: ManifestVersion + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadManifest(dir); err == nil {
+		t.Errorf("ReadManifest() accepted version %d", ManifestVersion+1)
+	}
+}